@@ -0,0 +1,75 @@
+package v1alpha1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"cloudfoundry.org/cf-crd-explorations/api/v1beta1"
+)
+
+// ConvertTo converts this App to the Hub version (v1beta1)
+func (src *App) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.App)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec, dst.Status = appToV1beta1(src.Spec, src.Status)
+
+	restored := &v1beta1.App{}
+	if ok, err := unmarshalData(dst, V1beta1DataAnnotation, &restored.Spec, &restored.Status); err != nil {
+		return err
+	} else if ok {
+		if spec, status := appFromV1beta1(restored.Spec, restored.Status); semanticEqual(spec, src.Spec) && semanticEqual(status, src.Status) {
+			dst.Spec, dst.Status = restored.Spec, restored.Status
+		}
+	}
+
+	if spec, status := appFromV1beta1(dst.Spec, dst.Status); !semanticEqual(spec, src.Spec) || !semanticEqual(status, src.Status) {
+		return marshalData(dst, V1alpha1DataAnnotation, src.Spec, src.Status)
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version
+func (dst *App) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.App)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec, dst.Status = appFromV1beta1(src.Spec, src.Status)
+
+	restored := &App{}
+	if ok, err := unmarshalData(dst, V1alpha1DataAnnotation, &restored.Spec, &restored.Status); err != nil {
+		return err
+	} else if ok {
+		if spec, status := appToV1beta1(restored.Spec, restored.Status); semanticEqual(spec, src.Spec) && semanticEqual(status, src.Status) {
+			dst.Spec, dst.Status = restored.Spec, restored.Status
+		}
+	}
+
+	if spec, status := appToV1beta1(dst.Spec, dst.Status); !semanticEqual(spec, src.Spec) || !semanticEqual(status, src.Status) {
+		return marshalData(dst, V1beta1DataAnnotation, src.Spec, src.Status)
+	}
+	return nil
+}
+
+func appToV1beta1(spec AppSpec, status AppStatus) (v1beta1.AppSpec, v1beta1.AppStatus) {
+	return v1beta1.AppSpec{
+		Name:         spec.Name,
+		DesiredState: v1beta1.DesiredState(spec.DesiredState),
+		Type:         v1beta1.LifecycleType(spec.Type),
+		Lifecycle: v1beta1.Lifecycle{
+			Data: v1beta1.LifecycleData(spec.Lifecycle.Data),
+		},
+		EnvSecretName:     spec.EnvSecretName,
+		CurrentDropletRef: v1beta1.DropletReference(spec.CurrentDropletRef),
+	}, v1beta1.AppStatus{}
+}
+
+func appFromV1beta1(spec v1beta1.AppSpec, status v1beta1.AppStatus) (AppSpec, AppStatus) {
+	return AppSpec{
+		Name:         spec.Name,
+		DesiredState: DesiredState(spec.DesiredState),
+		Type:         LifecycleType(spec.Type),
+		Lifecycle: Lifecycle{
+			Data: LifecycleData(spec.Lifecycle.Data),
+		},
+		EnvSecretName:     spec.EnvSecretName,
+		CurrentDropletRef: DropletReference(spec.CurrentDropletRef),
+	}, AppStatus{}
+}
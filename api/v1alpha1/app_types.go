@@ -34,6 +34,8 @@ type AppSpec struct {
 	// Valid values are:
 	// "STARTED": App is started
 	// "STOPPED": App is stopped
+	// Defaulted by the mutating webhook when left empty
+	// +optional
 	DesiredState DesiredState `json:"desiredState"`
 
 	// Specifies the CF Lifecycle type:
@@ -0,0 +1,140 @@
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"cloudfoundry.org/cf-crd-explorations/api/v1beta1"
+)
+
+// ConvertTo converts this AppManifest to the Hub version (v1beta1)
+func (src *AppManifest) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.AppManifest)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec, dst.Status = appManifestToV1beta1(src.Spec, src.Status)
+
+	restored := &v1beta1.AppManifest{}
+	if ok, err := unmarshalData(dst, V1beta1DataAnnotation, &restored.Spec, &restored.Status); err != nil {
+		return err
+	} else if ok {
+		if spec, status := appManifestFromV1beta1(restored.Spec, restored.Status); semanticEqual(spec, src.Spec) && semanticEqual(status, src.Status) {
+			dst.Spec, dst.Status = restored.Spec, restored.Status
+		}
+	}
+
+	if spec, status := appManifestFromV1beta1(dst.Spec, dst.Status); !semanticEqual(spec, src.Spec) || !semanticEqual(status, src.Status) {
+		return marshalData(dst, V1alpha1DataAnnotation, src.Spec, src.Status)
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version
+func (dst *AppManifest) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.AppManifest)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec, dst.Status = appManifestFromV1beta1(src.Spec, src.Status)
+
+	restored := &AppManifest{}
+	if ok, err := unmarshalData(dst, V1alpha1DataAnnotation, &restored.Spec, &restored.Status); err != nil {
+		return err
+	} else if ok {
+		if spec, status := appManifestToV1beta1(restored.Spec, restored.Status); semanticEqual(spec, src.Spec) && semanticEqual(status, src.Status) {
+			dst.Spec, dst.Status = restored.Spec, restored.Status
+		}
+	}
+
+	if spec, status := appManifestToV1beta1(dst.Spec, dst.Status); !semanticEqual(spec, src.Spec) || !semanticEqual(status, src.Status) {
+		return marshalData(dst, V1beta1DataAnnotation, src.Spec, src.Status)
+	}
+	return nil
+}
+
+func appManifestToV1beta1(spec AppManifestSpec, status AppManifestStatus) (v1beta1.AppManifestSpec, v1beta1.AppManifestStatus) {
+	var routes []v1beta1.Route
+	for _, route := range spec.Routes {
+		routes = append(routes, v1beta1.Route(route))
+	}
+
+	var processes []v1beta1.ManifestProcess
+	for _, process := range spec.Processes {
+		processes = append(processes, v1beta1.ManifestProcess{
+			Type:                         process.Type,
+			Command:                      process.Command,
+			Memory:                       cfByteSizeToQuantity(process.Memory),
+			DiskQuota:                    cfByteSizeToQuantity(process.DiskQuota),
+			HealthCheckHTTPEndpoint:      process.HealthCheckHTTPEndpoint,
+			HealthCheckType:              process.HealthCheckType,
+			Timeout:                      process.Timeout,
+			HealthCheckInvocationTimeout: process.HealthCheckInvocationTimeout,
+			Instances:                    process.Instances,
+		})
+	}
+
+	var sidecars []v1beta1.Sidecar
+	for _, sidecar := range spec.Sidecars {
+		memory := cfByteSizeToQuantity(sidecar.Memory)
+		if memory == nil {
+			memory = &resource.Quantity{}
+		}
+		sidecars = append(sidecars, v1beta1.Sidecar{
+			Name:         sidecar.Name,
+			ProcessTypes: sidecar.ProcessTypes,
+			Command:      sidecar.Command,
+			Memory:       *memory,
+		})
+	}
+
+	return v1beta1.AppManifestSpec{
+		Name:       spec.Name,
+		Buildpacks: spec.Buildpacks,
+		Env:        spec.Env,
+		Routes:     routes,
+		Services:   spec.Services,
+		Stack:      spec.Stack,
+		Processes:  processes,
+		Sidecars:   sidecars,
+	}, v1beta1.AppManifestStatus{}
+}
+
+func appManifestFromV1beta1(spec v1beta1.AppManifestSpec, status v1beta1.AppManifestStatus) (AppManifestSpec, AppManifestStatus) {
+	var routes []Route
+	for _, route := range spec.Routes {
+		routes = append(routes, Route(route))
+	}
+
+	var processes []ManifestProcess
+	for _, process := range spec.Processes {
+		processes = append(processes, ManifestProcess{
+			Type:                         process.Type,
+			Command:                      process.Command,
+			Memory:                       quantityToCFByteSize(process.Memory),
+			DiskQuota:                    quantityToCFByteSize(process.DiskQuota),
+			HealthCheckHTTPEndpoint:      process.HealthCheckHTTPEndpoint,
+			HealthCheckType:              process.HealthCheckType,
+			Timeout:                      process.Timeout,
+			HealthCheckInvocationTimeout: process.HealthCheckInvocationTimeout,
+			Instances:                    process.Instances,
+		})
+	}
+
+	var sidecars []Sidecar
+	for _, sidecar := range spec.Sidecars {
+		sidecars = append(sidecars, Sidecar{
+			Name:         sidecar.Name,
+			ProcessTypes: sidecar.ProcessTypes,
+			Command:      sidecar.Command,
+			Memory:       quantityToCFByteSize(&sidecar.Memory),
+		})
+	}
+
+	return AppManifestSpec{
+		Name:       spec.Name,
+		Buildpacks: spec.Buildpacks,
+		Env:        spec.Env,
+		Routes:     routes,
+		Services:   spec.Services,
+		Stack:      spec.Stack,
+		Processes:  processes,
+		Sidecars:   sidecars,
+	}, AppManifestStatus{}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AuditEventSpec defines what happened, it is not changed once the AuditEvent is created
+type AuditEventSpec struct {
+	// Specifies the CF type of the event, e.g. audit.app.start
+	Type string `json:"type"`
+
+	// Specifies who caused the event, a CF user or the system
+	Actor AuditEventActor `json:"actor"`
+
+	// Specifies what the event happened to, e.g. an App
+	Target AuditEventTarget `json:"target"`
+
+	// Specifies details of the event, e.g. the GUID of the droplet mapped to an App
+	// +optional
+	Data map[string]string `json:"data,omitempty"`
+}
+
+type AuditEventActor struct {
+	GUID string `json:"guid"`
+	// Specifies the kind of actor, user or system
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type AuditEventTarget struct {
+	GUID string `json:"guid"`
+	// Specifies the kind of target, e.g. app
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:shortName=cfauditevent
+//+kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+//+kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.target.name`
+//+kubebuilder:printcolumn:name="Actor",type=string,JSONPath=`.spec.actor.name`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// AuditEvent is the Schema for the auditevents API, it records who did what to a resource of a space like the audit
+// events of Cloud Controller. AuditEvents are not owned by their target, so they outlive it
+type AuditEvent struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AuditEventSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// AuditEventList contains a list of AuditEvent
+type AuditEventList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AuditEvent `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AuditEvent{}, &AuditEventList{})
+}
@@ -0,0 +1,89 @@
+package v1alpha1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"cloudfoundry.org/cf-crd-explorations/api/v1beta1"
+)
+
+// ConvertTo converts this Build to the Hub version (v1beta1)
+func (src *Build) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.Build)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec, dst.Status = buildToV1beta1(src.Spec, src.Status)
+
+	restored := &v1beta1.Build{}
+	if ok, err := unmarshalData(dst, V1beta1DataAnnotation, &restored.Spec, &restored.Status); err != nil {
+		return err
+	} else if ok {
+		if spec, status := buildFromV1beta1(restored.Spec, restored.Status); semanticEqual(spec, src.Spec) && semanticEqual(status, src.Status) {
+			dst.Spec, dst.Status = restored.Spec, restored.Status
+		}
+	}
+
+	if spec, status := buildFromV1beta1(dst.Spec, dst.Status); !semanticEqual(spec, src.Spec) || !semanticEqual(status, src.Status) {
+		return marshalData(dst, V1alpha1DataAnnotation, src.Spec, src.Status)
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version
+func (dst *Build) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.Build)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec, dst.Status = buildFromV1beta1(src.Spec, src.Status)
+
+	restored := &Build{}
+	if ok, err := unmarshalData(dst, V1alpha1DataAnnotation, &restored.Spec, &restored.Status); err != nil {
+		return err
+	} else if ok {
+		if spec, status := buildToV1beta1(restored.Spec, restored.Status); semanticEqual(spec, src.Spec) && semanticEqual(status, src.Status) {
+			dst.Spec, dst.Status = restored.Spec, restored.Status
+		}
+	}
+
+	if spec, status := buildToV1beta1(dst.Spec, dst.Status); !semanticEqual(spec, src.Spec) || !semanticEqual(status, src.Status) {
+		return marshalData(dst, V1beta1DataAnnotation, src.Spec, src.Status)
+	}
+	return nil
+}
+
+func buildToV1beta1(spec BuildSpec, status BuildStatus) (v1beta1.BuildSpec, v1beta1.BuildStatus) {
+	return v1beta1.BuildSpec{
+		Type:               v1beta1.LifecycleType(spec.Type),
+		PackageRef:         v1beta1.PackageReference(spec.PackageRef),
+		AppRef:             v1beta1.ApplicationReference(spec.AppRef),
+		LifecycleData:      v1beta1.LifecycleData(spec.LifecycleData),
+		KpackBuildSelector: v1beta1.KpackBuildSelector(spec.KpackBuildSelector),
+		KpackImageTemplate: v1beta1.KpackImageTemplate(spec.KpackImageTemplate),
+	}, v1beta1.BuildStatus{
+		DropletReference:    v1beta1.DropletReference(status.DropletReference),
+		Conditions:          status.Conditions,
+		Phase:               v1beta1.BuildPhase(status.Phase),
+		StartedAt:           status.StartedAt,
+		FinishedAt:          status.FinishedAt,
+		StagingDuration:     status.StagingDuration,
+		KpackBuildReference: v1beta1.KpackBuildReference(status.KpackBuildReference),
+		Error:               (*v1beta1.BuildError)(status.Error),
+	}
+}
+
+func buildFromV1beta1(spec v1beta1.BuildSpec, status v1beta1.BuildStatus) (BuildSpec, BuildStatus) {
+	return BuildSpec{
+		Type:               LifecycleType(spec.Type),
+		PackageRef:         PackageReference(spec.PackageRef),
+		AppRef:             ApplicationReference(spec.AppRef),
+		LifecycleData:      LifecycleData(spec.LifecycleData),
+		KpackBuildSelector: KpackBuildSelector(spec.KpackBuildSelector),
+		KpackImageTemplate: KpackImageTemplate(spec.KpackImageTemplate),
+	}, BuildStatus{
+		DropletReference:    DropletReference(status.DropletReference),
+		Conditions:          status.Conditions,
+		Phase:               BuildPhase(status.Phase),
+		StartedAt:           status.StartedAt,
+		FinishedAt:          status.FinishedAt,
+		StagingDuration:     status.StagingDuration,
+		KpackBuildReference: KpackBuildReference(status.KpackBuildReference),
+		Error:               (*BuildError)(status.Error),
+	}
+}
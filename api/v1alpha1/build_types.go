@@ -52,12 +52,56 @@ type BuildStatus struct {
 	// TODO: figure out why omitempty behaves weird, seems like kubectl doesn't even represent internally with an empty slice
 	// Contains the current status of the build
 	Conditions []metav1.Condition `json:"conditions"`
+
+	// Describes where the build is in staging, it moves from PENDING to STAGING and ends STAGED or FAILED
+	// +optional
+	Phase BuildPhase `json:"phase,omitempty"`
+
+	// The time staging started
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// The time the build was STAGED or FAILED
+	// +optional
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+
+	// How long staging took, set once the build is STAGED or FAILED
+	// +optional
+	StagingDuration *metav1.Duration `json:"stagingDuration,omitempty"`
+
+	// Contains a reference to the kpack Build that staged a buildpack build
+	// +optional
+	KpackBuildReference KpackBuildReference `json:"kpackBuildRef,omitempty"`
+
+	// Describes why the build FAILED, in the format of CF API errors
+	// +optional
+	Error *BuildError `json:"error,omitempty"`
+}
+
+// BuildPhase is where a Build is in staging
+// +kubebuilder:validation:Enum=PENDING;STAGING;STAGED;FAILED
+type BuildPhase string
+
+const (
+	BuildPendingPhase BuildPhase = "PENDING"
+	BuildStagingPhase BuildPhase = "STAGING"
+	BuildStagedPhase  BuildPhase = "STAGED"
+	BuildFailedPhase  BuildPhase = "FAILED"
+)
+
+// BuildError uses the codes and titles of the staging errors of Cloud Controller
+type BuildError struct {
+	Code   int    `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 // Build is the Schema for the builds API
 //+kubebuilder:resource:shortName=cfb;cfbuild
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 type Build struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
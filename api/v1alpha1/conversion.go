@@ -0,0 +1,191 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"cloudfoundry.org/cf-crd-explorations/api/v1beta1"
+)
+
+// v1beta1 is the hub, every kind in this package converts to and from it through ConvertTo and ConvertFrom
+// See: https://book.kubebuilder.io/multiversion-tutorial/conversion.html
+//
+// Some v1alpha1 values have no exact v1beta1 equivalent (e.g. a manifest memory of "1024M" becomes 1Gi) and the other
+// way around (e.g. a memory limit that is not a whole number of MB). When a conversion would lose such a value, the
+// original spec and status are kept in an annotation on the converted object, and put back when the object is
+// converted back as long as it has not been changed in the meantime. This way objects read and written through
+// either version never lose data.
+const (
+	// V1alpha1DataAnnotation holds the v1alpha1 spec and status of a v1beta1 object that cannot reproduce them exactly
+	V1alpha1DataAnnotation = "apps.cloudfoundry.org/v1alpha1-data"
+	// V1beta1DataAnnotation holds the v1beta1 spec and status of a v1alpha1 object that cannot reproduce them exactly
+	V1beta1DataAnnotation = "apps.cloudfoundry.org/v1beta1-data"
+)
+
+// +kubebuilder:object:generate=false
+type conversionData struct {
+	Spec   json.RawMessage `json:"spec"`
+	Status json.RawMessage `json:"status,omitempty"`
+}
+
+// marshalData stores spec and status under the annotation key on obj
+func marshalData(obj metav1.Object, key string, spec, status interface{}) error {
+	var data conversionData
+	var err error
+	if data.Spec, err = json.Marshal(spec); err != nil {
+		return err
+	}
+	if data.Status, err = json.Marshal(status); err != nil {
+		return err
+	}
+	annotation, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = string(annotation)
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// unmarshalData removes the annotation key from obj and decodes it into spec and status, it returns false if obj had no such annotation
+func unmarshalData(obj metav1.Object, key string, spec, status interface{}) (bool, error) {
+	annotations := obj.GetAnnotations()
+	annotation, ok := annotations[key]
+	if !ok {
+		return false, nil
+	}
+	delete(annotations, key)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+
+	var data conversionData
+	if err := json.Unmarshal([]byte(annotation), &data); err != nil {
+		return false, fmt.Errorf("invalid %s annotation: %w", key, err)
+	}
+	if err := json.Unmarshal(data.Spec, spec); err != nil {
+		return false, fmt.Errorf("invalid spec in %s annotation: %w", key, err)
+	}
+	if len(data.Status) > 0 {
+		if err := json.Unmarshal(data.Status, status); err != nil {
+			return false, fmt.Errorf("invalid status in %s annotation: %w", key, err)
+		}
+	}
+	return true, nil
+}
+
+func semanticEqual(a, b interface{}) bool {
+	return equality.Semantic.DeepEqual(a, b)
+}
+
+const mebibyte = 1024 * 1024
+
+// megabytesToQuantity converts the MB used for CF memory and disk limits, which are mebibytes, into a Quantity
+func megabytesToQuantity(megabytes int64) resource.Quantity {
+	return *resource.NewQuantity(megabytes*mebibyte, resource.BinarySI)
+}
+
+// quantityToMegabytes rounds up, so a converted limit is never lower than the original one
+func quantityToMegabytes(quantity resource.Quantity) int64 {
+	value := quantity.Value()
+	megabytes := value / mebibyte
+	if value%mebibyte > 0 {
+		megabytes++
+	}
+	return megabytes
+}
+
+var cfByteSizePattern = regexp.MustCompile(`^(?i)\s*(\d+)\s*(M|MB|G|GB|T|TB)\s*$`)
+
+// cfByteSizeToQuantity parses the sizes used in CF manifests, e.g. "512M" or "1G", where units are powers of 1024
+// An empty or unparseable size gives nil
+func cfByteSizeToQuantity(size string) *resource.Quantity {
+	matches := cfByteSizePattern.FindStringSubmatch(size)
+	if matches == nil {
+		return nil
+	}
+	value, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	suffix := map[string]string{"M": "Mi", "G": "Gi", "T": "Ti"}[strings.ToUpper(matches[2][:1])]
+	quantity, err := resource.ParseQuantity(fmt.Sprintf("%d%s", value, suffix))
+	if err != nil {
+		return nil
+	}
+	return &quantity
+}
+
+// quantityToCFByteSize formats a Quantity the way CF manifests write sizes, in whole G if possible and in M otherwise
+func quantityToCFByteSize(quantity *resource.Quantity) string {
+	if quantity == nil || quantity.IsZero() {
+		return ""
+	}
+	megabytes := quantityToMegabytes(*quantity)
+	if megabytes%1024 == 0 {
+		return fmt.Sprintf("%dG", megabytes/1024)
+	}
+	return fmt.Sprintf("%dM", megabytes)
+}
+
+// processTypesToV1beta1 flattens the single-entry maps of v1alpha1 into a list sorted by process type
+func processTypesToV1beta1(processTypes []ProcessType) []v1beta1.ProcessType {
+	commands := map[string]string{}
+	for _, processType := range processTypes {
+		for name, command := range processType {
+			commands[name] = command
+		}
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+
+	result := make([]v1beta1.ProcessType, 0, len(commands))
+	for name, command := range commands {
+		result = append(result, v1beta1.ProcessType{Type: name, Command: command})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Type < result[j].Type })
+	return result
+}
+
+func processTypesFromV1beta1(processTypes []v1beta1.ProcessType) []ProcessType {
+	if len(processTypes) == 0 {
+		return nil
+	}
+	commands := ProcessType{}
+	for _, processType := range processTypes {
+		commands[processType.Type] = processType.Command
+	}
+	return []ProcessType{commands}
+}
+
+// kpackImageRefToV1beta1 maps the zero value used by v1alpha1 for "no kpack Image" to nil
+func kpackImageRefToV1beta1(ref KpackImageReference) *v1beta1.KpackImageReference {
+	if ref == (KpackImageReference{}) {
+		return nil
+	}
+	converted := v1beta1.KpackImageReference(ref)
+	return &converted
+}
+
+func kpackImageRefFromV1beta1(ref *v1beta1.KpackImageReference) KpackImageReference {
+	if ref == nil {
+		return KpackImageReference{}
+	}
+	return KpackImageReference(*ref)
+}
@@ -0,0 +1,199 @@
+package v1alpha1_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/api/v1beta1"
+)
+
+const fuzzIterations = 500
+
+type convertiblePair struct {
+	spoke func() conversion.Convertible
+	hub   func() conversion.Hub
+}
+
+var convertibleKinds = map[string]convertiblePair{
+	"App":         {func() conversion.Convertible { return &v1alpha1.App{} }, func() conversion.Hub { return &v1beta1.App{} }},
+	"AppManifest": {func() conversion.Convertible { return &v1alpha1.AppManifest{} }, func() conversion.Hub { return &v1beta1.AppManifest{} }},
+	"Build":       {func() conversion.Convertible { return &v1alpha1.Build{} }, func() conversion.Hub { return &v1beta1.Build{} }},
+	"Droplet":     {func() conversion.Convertible { return &v1alpha1.Droplet{} }, func() conversion.Hub { return &v1beta1.Droplet{} }},
+	"Package":     {func() conversion.Convertible { return &v1alpha1.Package{} }, func() conversion.Hub { return &v1beta1.Package{} }},
+	"Process":     {func() conversion.Convertible { return &v1alpha1.Process{} }, func() conversion.Hub { return &v1beta1.Process{} }},
+}
+
+func TestSpokeHubSpokeRoundTrip(t *testing.T) {
+	fuzzer := newFuzzer()
+	for kind, pair := range convertibleKinds {
+		for i := 0; i < fuzzIterations; i++ {
+			original := pair.spoke()
+			fuzzer.Fuzz(original)
+			normalize(t, original)
+
+			hub := pair.hub()
+			if err := original.DeepCopyObject().(conversion.Convertible).ConvertTo(hub); err != nil {
+				t.Fatalf("%s: converting to v1beta1 failed: %v", kind, err)
+			}
+			roundTripped := pair.spoke()
+			if err := roundTripped.ConvertFrom(hub); err != nil {
+				t.Fatalf("%s: converting from v1beta1 failed: %v", kind, err)
+			}
+
+			if !equality.Semantic.DeepEqual(withoutTypeMeta(original), withoutTypeMeta(roundTripped)) {
+				t.Fatalf("%s: v1alpha1 -> v1beta1 -> v1alpha1 is lossy\noriginal: %+v\nround tripped: %+v", kind, original, roundTripped)
+			}
+		}
+	}
+}
+
+func TestHubSpokeHubRoundTrip(t *testing.T) {
+	fuzzer := newFuzzer()
+	for kind, pair := range convertibleKinds {
+		for i := 0; i < fuzzIterations; i++ {
+			original := pair.hub()
+			fuzzer.Fuzz(original)
+			normalize(t, original)
+
+			spoke := pair.spoke()
+			if err := spoke.ConvertFrom(original.DeepCopyObject().(conversion.Hub)); err != nil {
+				t.Fatalf("%s: converting from v1beta1 failed: %v", kind, err)
+			}
+			roundTripped := pair.hub()
+			if err := spoke.ConvertTo(roundTripped); err != nil {
+				t.Fatalf("%s: converting to v1beta1 failed: %v", kind, err)
+			}
+
+			if !equality.Semantic.DeepEqual(withoutTypeMeta(original), withoutTypeMeta(roundTripped)) {
+				t.Fatalf("%s: v1beta1 -> v1alpha1 -> v1beta1 is lossy\noriginal: %+v\nround tripped: %+v", kind, original, roundTripped)
+			}
+		}
+	}
+}
+
+func TestConvertDropletToV1beta1(t *testing.T) {
+	droplet := &v1alpha1.Droplet{
+		Spec: v1alpha1.DropletSpec{
+			Type:         v1alpha1.DockerLifecycle,
+			ProcessTypes: []v1alpha1.ProcessType{{"worker": "bundle exec sidekiq", "web": "bundle exec rackup"}},
+		},
+	}
+
+	converted := &v1beta1.Droplet{}
+	if err := droplet.ConvertTo(converted); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []v1beta1.ProcessType{
+		{Type: "web", Command: "bundle exec rackup"},
+		{Type: "worker", Command: "bundle exec sidekiq"},
+	}
+	if !equality.Semantic.DeepEqual(converted.Spec.ProcessTypes, expected) {
+		t.Errorf("expected process types %v, got %v", expected, converted.Spec.ProcessTypes)
+	}
+	if converted.Status.KpackImageRef != nil {
+		t.Errorf("expected no kpack image reference for a docker droplet, got %v", converted.Status.KpackImageRef)
+	}
+	if _, ok := converted.Annotations[v1alpha1.V1alpha1DataAnnotation]; ok {
+		t.Errorf("expected a typical droplet to convert without preserving v1alpha1 data")
+	}
+}
+
+func TestConvertProcessToV1beta1(t *testing.T) {
+	process := &v1alpha1.Process{
+		Spec: v1alpha1.ProcessSpec{MemoryMB: 500, DiskQuotaMB: 1024},
+	}
+
+	converted := &v1beta1.Process{}
+	if err := process.ConvertTo(converted); err != nil {
+		t.Fatal(err)
+	}
+
+	if converted.Spec.Memory.String() != "500Mi" || converted.Spec.DiskQuota.String() != "1Gi" {
+		t.Errorf("expected 500Mi memory and 1Gi disk, got %s and %s", converted.Spec.Memory.String(), converted.Spec.DiskQuota.String())
+	}
+	if _, ok := converted.Annotations[v1alpha1.V1alpha1DataAnnotation]; ok {
+		t.Errorf("expected a typical process to convert without preserving v1alpha1 data")
+	}
+}
+
+func TestConvertAppManifestToV1beta1(t *testing.T) {
+	manifest := &v1alpha1.AppManifest{
+		Spec: v1alpha1.AppManifestSpec{
+			Processes: []v1alpha1.ManifestProcess{{Type: "web", Memory: "512M", DiskQuota: "2G"}},
+		},
+	}
+
+	converted := &v1beta1.AppManifest{}
+	if err := manifest.ConvertTo(converted); err != nil {
+		t.Fatal(err)
+	}
+
+	process := converted.Spec.Processes[0]
+	if process.Memory.String() != "512Mi" || process.DiskQuota.String() != "2Gi" {
+		t.Errorf("expected 512Mi memory and 2Gi disk, got %s and %s", process.Memory.String(), process.DiskQuota.String())
+	}
+}
+
+func newFuzzer() *fuzz.Fuzzer {
+	return fuzz.New().
+		NilChance(0.2).
+		NumElements(0, 3).
+		RandSource(rand.NewSource(1)).
+		Funcs(
+			// Only the fields we care about, the rest of ObjectMeta is copied verbatim by every conversion
+			func(meta *metav1.ObjectMeta, c fuzz.Continue) {
+				meta.Name = c.RandString()
+				meta.Namespace = c.RandString()
+				c.Fuzz(&meta.Labels)
+				c.Fuzz(&meta.Annotations)
+				c.Fuzz(&meta.Generation)
+			},
+			func(q *resource.Quantity, c fuzz.Continue) {
+				*q = *resource.NewQuantity(c.Int63n(1<<40), resource.BinarySI)
+			},
+			// Services are stored as raw JSON, which has to be valid for the conversion annotations
+			func(raw *runtime.RawExtension, c fuzz.Continue) {
+				raw.Raw = []byte(fmt.Sprintf(`{"name":%q}`, c.RandString()))
+			},
+			// Give the CF size parser something to work with besides random strings
+			func(process *v1alpha1.ManifestProcess, c fuzz.Continue) {
+				c.FuzzNoCustom(process)
+				if c.RandBool() {
+					process.Memory = fmt.Sprintf("%d%s", c.Int63n(4096), []string{"M", "MB", "G", "g"}[c.Intn(4)])
+				}
+			},
+		)
+}
+
+// normalize passes obj through JSON like the API server does, e.g. empty lists become nil
+func normalize(t *testing.T, obj runtime.Object) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh := obj.DeepCopyObject()
+	reflect.ValueOf(fresh).Elem().Set(reflect.Zero(reflect.TypeOf(fresh).Elem()))
+	if err := json.Unmarshal(data, fresh); err != nil {
+		t.Fatal(err)
+	}
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(fresh).Elem())
+}
+
+func withoutTypeMeta(obj runtime.Object) runtime.Object {
+	copied := obj.DeepCopyObject()
+	copied.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+	return copied
+}
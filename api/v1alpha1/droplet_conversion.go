@@ -0,0 +1,81 @@
+package v1alpha1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"cloudfoundry.org/cf-crd-explorations/api/v1beta1"
+)
+
+// ConvertTo converts this Droplet to the Hub version (v1beta1)
+func (src *Droplet) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.Droplet)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec, dst.Status = dropletToV1beta1(src.Spec, src.Status)
+
+	restored := &v1beta1.Droplet{}
+	if ok, err := unmarshalData(dst, V1beta1DataAnnotation, &restored.Spec, &restored.Status); err != nil {
+		return err
+	} else if ok {
+		if spec, status := dropletFromV1beta1(restored.Spec, restored.Status); semanticEqual(spec, src.Spec) && semanticEqual(status, src.Status) {
+			dst.Spec, dst.Status = restored.Spec, restored.Status
+		}
+	}
+
+	if spec, status := dropletFromV1beta1(dst.Spec, dst.Status); !semanticEqual(spec, src.Spec) || !semanticEqual(status, src.Status) {
+		return marshalData(dst, V1alpha1DataAnnotation, src.Spec, src.Status)
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version
+func (dst *Droplet) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.Droplet)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec, dst.Status = dropletFromV1beta1(src.Spec, src.Status)
+
+	restored := &Droplet{}
+	if ok, err := unmarshalData(dst, V1alpha1DataAnnotation, &restored.Spec, &restored.Status); err != nil {
+		return err
+	} else if ok {
+		if spec, status := dropletToV1beta1(restored.Spec, restored.Status); semanticEqual(spec, src.Spec) && semanticEqual(status, src.Status) {
+			dst.Spec, dst.Status = restored.Spec, restored.Status
+		}
+	}
+
+	if spec, status := dropletToV1beta1(dst.Spec, dst.Status); !semanticEqual(spec, src.Spec) || !semanticEqual(status, src.Status) {
+		return marshalData(dst, V1beta1DataAnnotation, src.Spec, src.Status)
+	}
+	return nil
+}
+
+func dropletToV1beta1(spec DropletSpec, status DropletStatus) (v1beta1.DropletSpec, v1beta1.DropletStatus) {
+	return v1beta1.DropletSpec{
+		Type:         v1beta1.LifecycleType(spec.Type),
+		AppRef:       v1beta1.ApplicationReference(spec.AppRef),
+		BuildRef:     v1beta1.BuildReference(spec.BuildRef),
+		Registry:     v1beta1.Registry(spec.Registry),
+		ProcessTypes: processTypesToV1beta1(spec.ProcessTypes),
+		Ports:        spec.Ports,
+	}, v1beta1.DropletStatus{
+		KpackImageRef: kpackImageRefToV1beta1(status.ImageRef),
+		ResolvedImage: status.ResolvedImage,
+		LifecycleData: v1beta1.DockerLifecycleData(status.LifecycleData),
+		Conditions:    status.Conditions,
+	}
+}
+
+func dropletFromV1beta1(spec v1beta1.DropletSpec, status v1beta1.DropletStatus) (DropletSpec, DropletStatus) {
+	return DropletSpec{
+		Type:         LifecycleType(spec.Type),
+		AppRef:       ApplicationReference(spec.AppRef),
+		BuildRef:     BuildReference(spec.BuildRef),
+		Registry:     Registry(spec.Registry),
+		ProcessTypes: processTypesFromV1beta1(spec.ProcessTypes),
+		Ports:        spec.Ports,
+	}, DropletStatus{
+		ImageRef:      kpackImageRefFromV1beta1(status.KpackImageRef),
+		ResolvedImage: status.ResolvedImage,
+		LifecycleData: DockerLifecycleData(status.LifecycleData),
+		Conditions:    status.Conditions,
+	}
+}
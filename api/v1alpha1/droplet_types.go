@@ -62,6 +62,10 @@ type DropletStatus struct {
 	// to the original design of the CF Droplet and only refer to a static image
 	ImageRef KpackImageReference `json:"imageRef,omitempty"`
 
+	// Immutable digest reference (image@sha256:...) of spec.registry.image, resolved once when the Droplet is first reconciled
+	// Processes only ever run this reference so a re-pushed tag cannot change the bits of an existing Droplet
+	ResolvedImage string `json:"resolvedImage,omitempty"`
+
 	// Describes Docker metadata including ports the container exposes
 	LifecycleData DockerLifecycleData `json:"lifecycleData,omitempty"`
 
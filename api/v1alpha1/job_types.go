@@ -0,0 +1,103 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// JobState is the CF state of an asynchronous operation
+type JobState string
+
+const (
+	JobProcessingState JobState = "PROCESSING"
+	JobCompleteState   JobState = "COMPLETE"
+	JobFailedState     JobState = "FAILED"
+)
+
+// JobSpec defines the desired state of Job
+type JobSpec struct {
+	// Specifies the CF operation the Job tracks, only deletes are asynchronous so far
+	// +kubebuilder:validation:Enum=app.delete;package.delete;build.delete;droplet.delete
+	Operation string `json:"operation"`
+
+	// Specifies the object the operation acts on, in the namespace of the Job
+	ResourceRef JobResourceReference `json:"resourceRef"`
+}
+
+// JobResourceReference names the object an operation acts on
+type JobResourceReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+// JobStatus defines the observed state of Job
+type JobStatus struct {
+	// Describes the state of the operation, a Job without a state is still PROCESSING
+	// +kubebuilder:validation:Enum=PROCESSING;COMPLETE;FAILED
+	// +optional
+	State JobState `json:"state,omitempty"`
+
+	// Describes why the operation FAILED, in the format of CF API errors
+	// +optional
+	Errors []JobError `json:"errors,omitempty"`
+
+	// Describes problems that did not fail the operation
+	// +optional
+	Warnings []JobWarning `json:"warnings,omitempty"`
+}
+
+type JobError struct {
+	Code   int    `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+type JobWarning struct {
+	Detail string `json:"detail"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName=cfjob
+//+kubebuilder:printcolumn:name="Operation",type=string,JSONPath=`.spec.operation`
+//+kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Job is the Schema for the jobs API, it tracks an asynchronous operation of the CF API like Cloud Controller jobs
+// Use jobs.apps.cloudfoundry.org or cfjob with kubectl, plain jobs are batch Jobs
+type Job struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   JobSpec   `json:"spec,omitempty"`
+	Status JobStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// JobList contains a list of Job
+type JobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Job `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Job{}, &JobList{})
+}
@@ -0,0 +1,83 @@
+package v1alpha1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"cloudfoundry.org/cf-crd-explorations/api/v1beta1"
+)
+
+// ConvertTo converts this Package to the Hub version (v1beta1)
+func (src *Package) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.Package)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec, dst.Status = packageToV1beta1(src.Spec, src.Status)
+
+	restored := &v1beta1.Package{}
+	if ok, err := unmarshalData(dst, V1beta1DataAnnotation, &restored.Spec, &restored.Status); err != nil {
+		return err
+	} else if ok {
+		if spec, status := packageFromV1beta1(restored.Spec, restored.Status); semanticEqual(spec, src.Spec) && semanticEqual(status, src.Status) {
+			dst.Spec, dst.Status = restored.Spec, restored.Status
+		}
+	}
+
+	if spec, status := packageFromV1beta1(dst.Spec, dst.Status); !semanticEqual(spec, src.Spec) || !semanticEqual(status, src.Status) {
+		return marshalData(dst, V1alpha1DataAnnotation, src.Spec, src.Status)
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version
+func (dst *Package) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.Package)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec, dst.Status = packageFromV1beta1(src.Spec, src.Status)
+
+	restored := &Package{}
+	if ok, err := unmarshalData(dst, V1alpha1DataAnnotation, &restored.Spec, &restored.Status); err != nil {
+		return err
+	} else if ok {
+		if spec, status := packageToV1beta1(restored.Spec, restored.Status); semanticEqual(spec, src.Spec) && semanticEqual(status, src.Status) {
+			dst.Spec, dst.Status = restored.Spec, restored.Status
+		}
+	}
+
+	if spec, status := packageToV1beta1(dst.Spec, dst.Status); !semanticEqual(spec, src.Spec) || !semanticEqual(status, src.Status) {
+		return marshalData(dst, V1beta1DataAnnotation, src.Spec, src.Status)
+	}
+	return nil
+}
+
+func packageToV1beta1(spec PackageSpec, status PackageStatus) (v1beta1.PackageSpec, v1beta1.PackageStatus) {
+	return v1beta1.PackageSpec{
+		Type:   v1beta1.PackageType(spec.Type),
+		AppRef: v1beta1.ApplicationReference(spec.AppRef),
+		Source: v1beta1.PackageSource{
+			Registry: v1beta1.Registry(spec.Source.Registry),
+			SubPath:  spec.Source.SubPath,
+		},
+	}, v1beta1.PackageStatus{
+		Checksum: v1beta1.Checksum{
+			Type:  v1beta1.CheckSumType(status.Checksum.Type),
+			Value: status.Checksum.Value,
+		},
+		Conditions: status.Conditions,
+	}
+}
+
+func packageFromV1beta1(spec v1beta1.PackageSpec, status v1beta1.PackageStatus) (PackageSpec, PackageStatus) {
+	return PackageSpec{
+		Type:   PackageType(spec.Type),
+		AppRef: ApplicationReference(spec.AppRef),
+		Source: PackageSource{
+			Registry: Registry(spec.Source.Registry),
+			SubPath:  spec.Source.SubPath,
+		},
+	}, PackageStatus{
+		Checksum: Checksum{
+			Type:  CheckSumType(status.Checksum.Type),
+			Value: status.Checksum.Value,
+		},
+		Conditions: status.Conditions,
+	}
+}
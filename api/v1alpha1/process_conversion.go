@@ -0,0 +1,111 @@
+package v1alpha1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"cloudfoundry.org/cf-crd-explorations/api/v1beta1"
+)
+
+// ConvertTo converts this Process to the Hub version (v1beta1)
+func (src *Process) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.Process)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec, dst.Status = processToV1beta1(src.Spec, src.Status)
+
+	restored := &v1beta1.Process{}
+	if ok, err := unmarshalData(dst, V1beta1DataAnnotation, &restored.Spec, &restored.Status); err != nil {
+		return err
+	} else if ok {
+		if spec, status := processFromV1beta1(restored.Spec, restored.Status); semanticEqual(spec, src.Spec) && semanticEqual(status, src.Status) {
+			dst.Spec, dst.Status = restored.Spec, restored.Status
+		}
+	}
+
+	if spec, status := processFromV1beta1(dst.Spec, dst.Status); !semanticEqual(spec, src.Spec) || !semanticEqual(status, src.Status) {
+		return marshalData(dst, V1alpha1DataAnnotation, src.Spec, src.Status)
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version
+func (dst *Process) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.Process)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec, dst.Status = processFromV1beta1(src.Spec, src.Status)
+
+	restored := &Process{}
+	if ok, err := unmarshalData(dst, V1alpha1DataAnnotation, &restored.Spec, &restored.Status); err != nil {
+		return err
+	} else if ok {
+		if spec, status := processToV1beta1(restored.Spec, restored.Status); semanticEqual(spec, src.Spec) && semanticEqual(status, src.Status) {
+			dst.Spec, dst.Status = restored.Spec, restored.Status
+		}
+	}
+
+	if spec, status := processToV1beta1(dst.Spec, dst.Status); !semanticEqual(spec, src.Spec) || !semanticEqual(status, src.Status) {
+		return marshalData(dst, V1beta1DataAnnotation, src.Spec, src.Status)
+	}
+	return nil
+}
+
+func processToV1beta1(spec ProcessSpec, status ProcessStatus) (v1beta1.ProcessSpec, v1beta1.ProcessStatus) {
+	var sidecars []v1beta1.ProcessSidecar
+	for _, sidecar := range spec.Sidecars {
+		sidecars = append(sidecars, v1beta1.ProcessSidecar{
+			Name:    sidecar.Name,
+			Command: sidecar.Command,
+			Memory:  megabytesToQuantity(sidecar.MemoryMB),
+		})
+	}
+
+	return v1beta1.ProcessSpec{
+		AppRef:      v1beta1.ApplicationReference(spec.AppRef),
+		ProcessType: spec.ProcessType,
+		Command:     spec.Command,
+		State:       v1beta1.DesiredState(spec.State),
+		HealthCheck: v1beta1.HealthCheck{
+			Type: v1beta1.HealthCheckType(spec.HealthCheck.Type),
+			Data: v1beta1.HealthCheckData(spec.HealthCheck.Data),
+		},
+		Instances: spec.Instances,
+		Memory:    megabytesToQuantity(spec.MemoryMB),
+		DiskQuota: megabytesToQuantity(spec.DiskQuotaMB),
+		Ports:     spec.Ports,
+		Sidecars:  sidecars,
+	}, v1beta1.ProcessStatus{
+		Instances:     status.Instances,
+		Conditions:    status.Conditions,
+		KpackImageRef: kpackImageRefToV1beta1(status.ImageRef),
+	}
+}
+
+func processFromV1beta1(spec v1beta1.ProcessSpec, status v1beta1.ProcessStatus) (ProcessSpec, ProcessStatus) {
+	var sidecars []ProcessSidecar
+	for _, sidecar := range spec.Sidecars {
+		sidecars = append(sidecars, ProcessSidecar{
+			Name:     sidecar.Name,
+			Command:  sidecar.Command,
+			MemoryMB: quantityToMegabytes(sidecar.Memory),
+		})
+	}
+
+	return ProcessSpec{
+		AppRef:      ApplicationReference(spec.AppRef),
+		ProcessType: spec.ProcessType,
+		Command:     spec.Command,
+		State:       DesiredState(spec.State),
+		HealthCheck: HealthCheck{
+			Type: HealthCheckType(spec.HealthCheck.Type),
+			Data: HealthCheckData(spec.HealthCheck.Data),
+		},
+		Instances:   spec.Instances,
+		MemoryMB:    quantityToMegabytes(spec.Memory),
+		DiskQuotaMB: quantityToMegabytes(spec.DiskQuota),
+		Ports:       spec.Ports,
+		Sidecars:    sidecars,
+	}, ProcessStatus{
+		Instances:  status.Instances,
+		Conditions: status.Conditions,
+		ImageRef:   kpackImageRefFromV1beta1(status.KpackImageRef),
+	}
+}
@@ -41,21 +41,26 @@ type ProcessSpec struct {
 	// Valid values are:
 	// "STARTED": App is started
 	// "STOPPED": App is stopped
+	// +optional
 	State DesiredState `json:"state"`
 
 	// Specifies the Liveness Probe (k8s) details of the Process
+	// +optional
 	HealthCheck HealthCheck `json:"healthCheck"`
 
 	// Specifies the number of Process replicas to deploy
 	Instances int `json:"instances"`
 
 	// Specifies the Process memory limit
+	// +optional
 	MemoryMB int64 `json:"memoryMB"`
 
 	// Specifies the Process disk limit
+	// +optional
 	DiskQuotaMB int64 `json:"diskQuotaMB"`
 
 	// Specifies the Process ports to expose
+	// +optional
 	Ports []int32 `json:"ports"`
 
 	// Specifies the sidecars to be run alongside the Process
@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // ApplicationReference defines App resource that owns to this Process
@@ -11,6 +12,17 @@ type ApplicationReference struct {
 	Name       string `json:"name"`
 }
 
+// AppOwnerReference makes an object owned by the App, so Kubernetes garbage collects it once the App is deleted
+// Objects fetched through a client have no TypeMeta, so the kind and version are not read from the App
+func AppOwnerReference(app *App) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: GroupVersion.String(),
+		Kind:       "App",
+		Name:       app.Name,
+		UID:        app.UID,
+	}
+}
+
 // PackageReference defines Package resource that is associated to this Build
 // a package gets a new build each time it is staged
 type PackageReference struct {
@@ -45,6 +57,13 @@ type KpackImageReference struct {
 	Name       string `json:"name"`
 }
 
+// KpackBuildReference is used by build to refer to the kpack Build that staged it
+type KpackBuildReference struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+	Name       string `json:"name"`
+}
+
 // Checksum defines checksum for packaged images for now
 type Checksum struct {
 	Type  CheckSumType `json:"type"`
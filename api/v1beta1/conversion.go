@@ -0,0 +1,11 @@
+package v1beta1
+
+// v1beta1 is the storage version and the hub every other version converts through
+// See: https://book.kubebuilder.io/multiversion-tutorial/conversion-concepts.html
+
+func (*App) Hub()         {}
+func (*AppManifest) Hub() {}
+func (*Build) Hub()       {}
+func (*Droplet) Hub()     {}
+func (*Package) Hub()     {}
+func (*Process) Hub()     {}
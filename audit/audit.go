@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+)
+
+//+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=auditevents,verbs=get;list;watch;create
+
+// The types of the audit events the shim and the reconcilers record, named like the ones of Cloud Controller
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#audit-event-types
+const (
+	AppCreate        = "audit.app.create"
+	AppUpdate        = "audit.app.update"
+	AppStart         = "audit.app.start"
+	AppStop          = "audit.app.stop"
+	AppDeleteRequest = "audit.app.delete-request"
+	AppDropletMapped = "audit.app.droplet.mapped"
+	AppDropletCreate = "audit.app.droplet.create"
+	AppDropletDelete = "audit.app.droplet.delete"
+	AppBuildCreate   = "audit.app.build.create"
+	AppPackageCreate = "audit.app.package.create"
+	AppPackageUpload = "audit.app.package.upload"
+	AppPackageDelete = "audit.app.package.delete"
+)
+
+const (
+	UserActorType   = "user"
+	SystemActorType = "system"
+	AppTargetType   = "app"
+)
+
+// SystemActor is the actor of the events the reconcilers record on their own, e.g. a droplet staging produced
+var SystemActor = appsv1alpha1.AuditEventActor{
+	GUID: "system",
+	Type: SystemActorType,
+	Name: "system",
+}
+
+// AppTarget is the target of the events of an App and of its packages, builds, droplets and processes
+func AppTarget(app *appsv1alpha1.App) appsv1alpha1.AuditEventTarget {
+	return appsv1alpha1.AuditEventTarget{
+		GUID: app.Name,
+		Type: AppTargetType,
+		Name: app.Spec.Name,
+	}
+}
+
+// Record creates an AuditEvent in namespace, the space the event is listed under
+// AuditEvents are named by a new GUID, so recording the same change twice leads to two of them
+func Record(ctx context.Context, c client.Writer, namespace string, eventType string, actor appsv1alpha1.AuditEventActor, target appsv1alpha1.AuditEventTarget, data map[string]string) error {
+	return c.Create(ctx, &appsv1alpha1.AuditEvent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      uuid.NewString(),
+			Namespace: namespace,
+		},
+		Spec: appsv1alpha1.AuditEventSpec{
+			Type:   eventType,
+			Actor:  actor,
+			Target: target,
+			Data:   data,
+		},
+	})
+}
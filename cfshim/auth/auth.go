@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"sync"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Identity is the user a bearer token was issued to, the shim acts on Kubernetes as this user
+type Identity struct {
+	// Name is the Kubernetes username the shim impersonates, e.g. the UAA user_name with the configured prefix
+	Name string
+	// GUID identifies the user in audit events, e.g. the UAA user_id
+	GUID   string
+	Groups []string
+}
+
+// Authenticator validates a bearer token and returns who it was issued to
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Identity, error)
+}
+
+type requestKey struct{}
+
+// request is what the shim knows about the caller while it serves one request, the authorization decisions and the
+// impersonating client are only kept until it answers
+type request struct {
+	identity *Identity
+
+	mu           sync.Mutex
+	decisions    map[authorizationv1.ResourceAttributes]bool
+	impersonated client.Client
+}
+
+// WithIdentity returns a copy of ctx in which Client acts as identity
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, requestKey{}, &request{
+		identity:  identity,
+		decisions: map[authorizationv1.ResourceAttributes]bool{},
+	})
+}
+
+// IdentityFrom returns the caller the request of ctx was authenticated as, or nil if it was not
+func IdentityFrom(ctx context.Context) *Identity {
+	if req := requestFrom(ctx); req != nil {
+		return req.identity
+	}
+	return nil
+}
+
+// AsShim returns a copy of ctx in which Client acts as the shim itself, for the records it keeps of what the caller
+// did, like audit events and jobs, that the caller must not be able to write on their own
+func AsShim(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestKey{}, (*request)(nil))
+}
+
+func requestFrom(ctx context.Context) *request {
+	req, _ := ctx.Value(requestKey{}).(*request)
+	return req
+}
@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+//+kubebuilder:rbac:groups="",resources=users;groups;serviceaccounts,verbs=impersonate
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// Client is the client of the shim handlers, it acts as the caller of the request in the context of each call, so
+// Kubernetes RBAC decides what they can do in which namespace
+//
+// Reads go through the cache of the manager, whose field indexes the handlers rely on, once a SubjectAccessReview
+// allowed them. Objects the caller may not read are not found, and Lists only return the ones of namespaces they may
+// list in, like Cloud Controller hides the resources of other spaces. Writes are made by impersonating the caller
+//
+// Without an Identity in the context, e.g. when authentication is off or for AsShim, Client acts as the shim itself
+type Client struct {
+	client.Client
+	config *rest.Config
+}
+
+// NewClient returns a Client that reads with c and impersonates callers with config, the config of c
+func NewClient(c client.Client, config *rest.Config) *Client {
+	return &Client{Client: c, config: config}
+}
+
+func (c *Client) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	req := requestFrom(ctx)
+	if req == nil {
+		return c.Client.Get(ctx, key, obj)
+	}
+
+	attributes, err := c.attributesOf(obj, "get", key.Namespace)
+	if err != nil {
+		return err
+	}
+	attributes.Name = key.Name
+	// A namespace is in itself as far as RBAC is concerned, a RoleBinding in it can allow getting it
+	if attributes.Group == "" && attributes.Resource == "namespaces" {
+		attributes.Namespace = key.Name
+	}
+
+	allowed, err := c.allowed(ctx, req, attributes)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return apierrors.NewNotFound(schema.GroupResource{Group: attributes.Group, Resource: attributes.Resource}, key.Name)
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *Client) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	req := requestFrom(ctx)
+	if req == nil {
+		return c.Client.List(ctx, list, opts...)
+	}
+
+	listOptions := (&client.ListOptions{}).ApplyOptions(opts)
+	attributes, err := c.attributesOf(list, "list", listOptions.Namespace)
+	if err != nil {
+		return err
+	}
+	allowed, err := c.allowed(ctx, req, attributes)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	if allowed {
+		return nil
+	}
+
+	// Keep the objects of the namespaces the caller may list in, if any
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	var visible []runtime.Object
+	for _, item := range items {
+		obj, ok := item.(client.Object)
+		if !ok {
+			return fmt.Errorf("unexpected %T in list", item)
+		}
+		attributes.Namespace = obj.GetNamespace()
+		allowed, err := c.allowed(ctx, req, attributes)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			visible = append(visible, item)
+		}
+	}
+	return meta.SetList(list, visible)
+}
+
+func (c *Client) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	w, err := c.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return w.Create(ctx, obj, opts...)
+}
+
+func (c *Client) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	w, err := c.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return w.Delete(ctx, obj, opts...)
+}
+
+func (c *Client) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w, err := c.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return w.Update(ctx, obj, opts...)
+}
+
+func (c *Client) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w, err := c.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return w.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *Client) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	w, err := c.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return w.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *Client) Status() client.StatusWriter {
+	return &statusWriter{c}
+}
+
+type statusWriter struct {
+	c *Client
+}
+
+func (s *statusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w, err := s.c.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return w.Status().Update(ctx, obj, opts...)
+}
+
+func (s *statusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w, err := s.c.writer(ctx)
+	if err != nil {
+		return err
+	}
+	return w.Status().Patch(ctx, obj, patch, opts...)
+}
+
+// writer returns the client that impersonates the caller of the request of ctx, it is created once per request
+func (c *Client) writer(ctx context.Context) (client.Client, error) {
+	req := requestFrom(ctx)
+	if req == nil {
+		return c.Client, nil
+	}
+
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	if req.impersonated == nil {
+		config := rest.CopyConfig(c.config)
+		config.Impersonate = rest.ImpersonationConfig{
+			UserName: req.identity.Name,
+			Groups:   req.identity.Groups,
+		}
+		impersonated, err := client.New(config, client.Options{Scheme: c.Scheme(), Mapper: c.RESTMapper()})
+		if err != nil {
+			return nil, fmt.Errorf("error creating client impersonating %s: %w", req.identity.Name, err)
+		}
+		req.impersonated = impersonated
+	}
+	return req.impersonated, nil
+}
+
+// attributesOf returns what RBAC checks when the caller does verb to obj, an object or a list of them, in namespace
+func (c *Client) attributesOf(obj runtime.Object, verb string, namespace string) (authorizationv1.ResourceAttributes, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return authorizationv1.ResourceAttributes{}, err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+
+	mapping, err := c.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return authorizationv1.ResourceAttributes{}, err
+	}
+	return authorizationv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      verb,
+		Group:     gvk.Group,
+		Resource:  mapping.Resource.Resource,
+	}, nil
+}
+
+// allowed asks Kubernetes whether the caller may do what attributes describe, each question is asked once per request
+func (c *Client) allowed(ctx context.Context, req *request, attributes authorizationv1.ResourceAttributes) (bool, error) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	if allowed, ok := req.decisions[attributes]; ok {
+		return allowed, nil
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attributes,
+			User:               req.identity.Name,
+			// The API server adds this group to impersonated users, the review must see them the same way
+			Groups: append([]string{authenticatedGroup}, req.identity.Groups...),
+		},
+	}
+	if err := c.Client.Create(ctx, review); err != nil {
+		return false, fmt.Errorf("error reviewing access of %s: %w", req.identity.Name, err)
+	}
+	req.decisions[attributes] = review.Status.Allowed
+	return review.Status.Allowed, nil
+}
@@ -0,0 +1,182 @@
+package auth_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/cfshim/auth"
+)
+
+// reviewingClient is the fake client with what the manager client has on top of it: a RESTMapper, and the API server
+// answering SubjectAccessReviews, which allows anything in the allowed namespaces
+type reviewingClient struct {
+	client.Client
+	mapper  meta.RESTMapper
+	allowed map[string]bool
+	reviews []authorizationv1.SubjectAccessReviewSpec
+}
+
+func (c *reviewingClient) RESTMapper() meta.RESTMapper {
+	return c.mapper
+}
+
+func (c *reviewingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if review, ok := obj.(*authorizationv1.SubjectAccessReview); ok {
+		c.reviews = append(c.reviews, review.Spec)
+		review.Status.Allowed = c.allowed[review.Spec.ResourceAttributes.Namespace]
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func newReviewingClient(allowedNamespaces ...string) *reviewingClient {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(appsv1alpha1.AddToScheme(scheme))
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+	mapper.Add(appsv1alpha1.GroupVersion.WithKind("App"), meta.RESTScopeNamespace)
+
+	c := &reviewingClient{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-space"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other-space"}},
+			&appsv1alpha1.App{ObjectMeta: metav1.ObjectMeta{Name: "my-app-guid", Namespace: "my-space"}},
+			&appsv1alpha1.App{ObjectMeta: metav1.ObjectMeta{Name: "other-app-guid", Namespace: "other-space"}},
+		).Build(),
+		mapper:  mapper,
+		allowed: map[string]bool{},
+	}
+	for _, namespace := range allowedNamespaces {
+		c.allowed[namespace] = true
+	}
+	return c
+}
+
+func withAlice() context.Context {
+	return auth.WithIdentity(context.Background(), &auth.Identity{Name: "uaa:alice", GUID: "alice-guid", Groups: []string{"uaa:developers"}})
+}
+
+func TestClientListsOnlyAllowedNamespaces(t *testing.T) {
+	reviewing := newReviewingClient("my-space")
+	c := auth.NewClient(reviewing, &rest.Config{})
+	ctx := withAlice()
+
+	apps := &appsv1alpha1.AppList{}
+	if err := c.List(ctx, apps); err != nil {
+		t.Fatal(err)
+	}
+	if len(apps.Items) != 1 || apps.Items[0].Name != "my-app-guid" {
+		t.Errorf("expected only the app of my-space, got %+v", apps.Items)
+	}
+
+	// The cluster-wide review and one per namespace
+	if len(reviewing.reviews) != 3 {
+		t.Fatalf("expected 3 reviews, got %+v", reviewing.reviews)
+	}
+	review := reviewing.reviews[0]
+	if review.User != "uaa:alice" || review.ResourceAttributes.Verb != "list" || review.ResourceAttributes.Resource != "apps" || review.ResourceAttributes.Namespace != "" {
+		t.Errorf("expected a cluster-wide review of listing apps by alice, got %+v", review)
+	}
+	if len(review.Groups) != 2 || review.Groups[0] != "system:authenticated" {
+		t.Errorf("expected the groups of alice with system:authenticated, got %v", review.Groups)
+	}
+
+	// The decisions are kept for the rest of the request
+	if err := c.List(ctx, &appsv1alpha1.AppList{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(reviewing.reviews) != 3 {
+		t.Errorf("expected no new reviews within the same request, got %d", len(reviewing.reviews))
+	}
+	if err := c.List(withAlice(), &appsv1alpha1.AppList{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(reviewing.reviews) != 6 {
+		t.Errorf("expected a new request to be reviewed again, got %d reviews", len(reviewing.reviews))
+	}
+
+	// Without an identity, the shim lists as itself
+	apps = &appsv1alpha1.AppList{}
+	if err := c.List(auth.AsShim(ctx), apps); err != nil {
+		t.Fatal(err)
+	}
+	if len(apps.Items) != 2 {
+		t.Errorf("expected the shim to list every app, got %+v", apps.Items)
+	}
+}
+
+func TestClientGetDeniedIsNotFound(t *testing.T) {
+	c := auth.NewClient(newReviewingClient("my-space"), &rest.Config{})
+	ctx := withAlice()
+
+	if err := c.Get(ctx, types.NamespacedName{Name: "my-app-guid", Namespace: "my-space"}, &appsv1alpha1.App{}); err != nil {
+		t.Errorf("expected the app of my-space to be found, got %v", err)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: "other-app-guid", Namespace: "other-space"}, &appsv1alpha1.App{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the app of other-space not to be found, got %v", err)
+	}
+
+	// A namespace is reviewed in itself, so a RoleBinding in it allows getting it
+	if err := c.Get(ctx, types.NamespacedName{Name: "my-space"}, &corev1.Namespace{}); err != nil {
+		t.Errorf("expected my-space to be found, got %v", err)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: "other-space"}, &corev1.Namespace{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected other-space not to be found, got %v", err)
+	}
+}
+
+func TestClientWritesAsCaller(t *testing.T) {
+	var impersonated []string
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		impersonated = append(impersonated, r.Header.Get("Impersonate-User"))
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+	defer apiServer.Close()
+
+	reviewing := newReviewingClient("my-space")
+	c := auth.NewClient(reviewing, &rest.Config{Host: apiServer.URL})
+
+	app := &appsv1alpha1.App{ObjectMeta: metav1.ObjectMeta{Name: "new-app-guid", Namespace: "my-space"}}
+	if err := c.Create(withAlice(), app); err != nil {
+		t.Fatal(err)
+	}
+	if len(impersonated) != 1 || impersonated[0] != "uaa:alice" {
+		t.Errorf("expected the app to be created impersonating alice, got %v", impersonated)
+	}
+	if err := reviewing.Client.Get(context.Background(), client.ObjectKeyFromObject(app), &appsv1alpha1.App{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the app not to be created as the shim, got %v", err)
+	}
+
+	shimApp := &appsv1alpha1.App{ObjectMeta: metav1.ObjectMeta{Name: "shim-app-guid", Namespace: "my-space"}}
+	if err := c.Create(auth.AsShim(withAlice()), shimApp); err != nil {
+		t.Fatal(err)
+	}
+	if len(impersonated) != 1 {
+		t.Errorf("expected the shim not to impersonate anyone, got %v", impersonated)
+	}
+	if err := reviewing.Client.Get(context.Background(), client.ObjectKeyFromObject(shimApp), &appsv1alpha1.App{}); err != nil {
+		t.Errorf("expected the app to be created as the shim, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-oidc"
+)
+
+// JWTConfig configures how JWTAuthenticator verifies tokens and maps their claims to an Identity
+type JWTConfig struct {
+	// JWKSURL serves the keys the tokens are signed with, e.g. https://uaa.example.com/token_keys
+	JWKSURL string
+	// Audience is checked against the aud claim, so only tokens issued to the shim's client are accepted
+	Audience string
+	// Issuer is checked against the iss claim when it is set
+	Issuer string
+	// UsernameClaim names the user, the sub claim does if the token has no such claim
+	UsernameClaim string
+	// UsernamePrefix is prepended to the username, so the users of the token issuer can not pass for other users of
+	// the cluster, e.g. uaa:
+	UsernamePrefix string
+	// GroupsClaim lists the groups of the user, if set. GroupsPrefix is prepended to each of them, UsernamePrefix is
+	// if it is not set
+	GroupsClaim  string
+	GroupsPrefix string
+}
+
+// reservedPrefix starts the names of the users and groups of Kubernetes itself, e.g. system:masters, the shim never
+// impersonates them
+const reservedPrefix = "system:"
+
+// JWTAuthenticator authenticates JWT bearer tokens signed by an OAuth server like UAA or an OIDC provider
+type JWTAuthenticator struct {
+	config   JWTConfig
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewJWTAuthenticator returns an Authenticator that verifies tokens with the keys of config.JWKSURL
+// The keys are fetched when the first token is verified, and again when a token is signed with a key it does not know
+func NewJWTAuthenticator(ctx context.Context, config JWTConfig) (*JWTAuthenticator, error) {
+	if config.JWKSURL == "" {
+		return nil, errors.New("JWT authentication needs a JWKS URL")
+	}
+	if config.Audience == "" {
+		return nil, errors.New("JWT authentication needs an audience")
+	}
+	if config.UsernamePrefix == "" {
+		return nil, errors.New("JWT authentication needs a username prefix")
+	}
+	if config.GroupsPrefix == "" {
+		config.GroupsPrefix = config.UsernamePrefix
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "sub"
+	}
+
+	keySet := oidc.NewRemoteKeySet(ctx, config.JWKSURL)
+	verifier := oidc.NewVerifier(config.Issuer, keySet, &oidc.Config{
+		ClientID:        config.Audience,
+		SkipIssuerCheck: config.Issuer == "",
+	})
+	return &JWTAuthenticator{config: config, verifier: verifier}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	idToken, err := a.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := map[string]interface{}{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	username, _ := claims[a.config.UsernameClaim].(string)
+	if username == "" {
+		username = idToken.Subject
+	}
+	if username == "" {
+		return nil, fmt.Errorf("token has neither a %s nor a sub claim", a.config.UsernameClaim)
+	}
+
+	// UAA tokens identify the user by user_id, sub is the same for users but names the client of client credentials
+	guid, _ := claims["user_id"].(string)
+	if guid == "" {
+		guid = idToken.Subject
+	}
+
+	identity := &Identity{
+		Name: a.config.UsernamePrefix + username,
+		GUID: guid,
+	}
+	if isReserved(username) || isReserved(identity.Name) {
+		return nil, fmt.Errorf("token of reserved user %s", username)
+	}
+	if a.config.GroupsClaim != "" {
+		for _, group := range stringsOfClaim(claims[a.config.GroupsClaim]) {
+			prefixed := a.config.GroupsPrefix + group
+			if isReserved(group) || isReserved(prefixed) {
+				return nil, fmt.Errorf("token of user %s in reserved group %s", username, group)
+			}
+			identity.Groups = append(identity.Groups, prefixed)
+		}
+	}
+	return identity, nil
+}
+
+func isReserved(name string) bool {
+	return strings.HasPrefix(name, reservedPrefix)
+}
+
+// stringsOfClaim returns the strings of a claim that is either a list of them or a single one, anything else has none
+func stringsOfClaim(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
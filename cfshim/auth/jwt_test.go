@@ -0,0 +1,107 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"cloudfoundry.org/cf-crd-explorations/cfshim/auth"
+)
+
+func TestJWTAuthenticator(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "key-1", Algorithm: "RS256", Use: "sig"},
+		}})
+	}))
+	defer jwks.Close()
+
+	authenticator, err := auth.NewJWTAuthenticator(context.Background(), auth.JWTConfig{
+		JWKSURL:        jwks.URL,
+		Audience:       "cf-api-shim",
+		Issuer:         "https://uaa.example.com/oauth/token",
+		UsernameClaim:  "user_name",
+		UsernamePrefix: "uaa:",
+		GroupsClaim:    "scope",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(signingKey *rsa.PrivateKey, claims map[string]interface{}) string {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: signingKey},
+			(&jose.SignerOptions{}).WithHeader("kid", "key-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	claims := map[string]interface{}{
+		"iss":       "https://uaa.example.com/oauth/token",
+		"aud":       []string{"cf-api-shim"},
+		"sub":       "user-guid",
+		"user_id":   "user-guid",
+		"user_name": "alice",
+		"scope":     []string{"cloud_controller.read", "cloud_controller.write"},
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}
+
+	identity, err := authenticator.Authenticate(context.Background(), sign(key, claims))
+	if err != nil {
+		t.Fatalf("expected the token to be accepted, got %v", err)
+	}
+	expected := &auth.Identity{
+		Name:   "uaa:alice",
+		GUID:   "user-guid",
+		Groups: []string{"uaa:cloud_controller.read", "uaa:cloud_controller.write"},
+	}
+	if !reflect.DeepEqual(identity, expected) {
+		t.Errorf("expected identity %+v, got %+v", expected, identity)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authenticator.Authenticate(context.Background(), sign(otherKey, claims)); err == nil {
+		t.Errorf("expected a token signed with another key to be rejected")
+	}
+
+	for description, change := range map[string]func(claims map[string]interface{}){
+		"an expired token":                func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"a token of another client":       func(claims map[string]interface{}) { claims["aud"] = []string{"cf"} },
+		"a token of a reserved user":      func(claims map[string]interface{}) { claims["user_name"] = "system:admin" },
+		"a token with a reserved group":   func(claims map[string]interface{}) { claims["scope"] = []string{"system:masters"} },
+		"a token of another token issuer": func(claims map[string]interface{}) { claims["iss"] = "https://other.example.com" },
+	} {
+		changed := map[string]interface{}{}
+		for name, value := range claims {
+			changed[name] = value
+		}
+		change(changed)
+		if _, err := authenticator.Authenticate(context.Background(), sign(key, changed)); err == nil {
+			t.Errorf("expected %s to be rejected", description)
+		}
+	}
+
+	if _, err := auth.NewJWTAuthenticator(context.Background(), auth.JWTConfig{JWKSURL: jwks.URL, UsernamePrefix: "uaa:"}); err == nil {
+		t.Errorf("expected a config without an audience to be rejected")
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+
+// authenticatedGroup is the group the API server puts every authenticated user in
+const authenticatedGroup = "system:authenticated"
+
+// TokenReviewAuthenticator authenticates bearer tokens the Kubernetes API server accepts, e.g. the tokens of the OIDC
+// provider of the cluster, by creating a TokenReview of them. Like JWTAuthenticator, it rejects the users and groups of
+// Kubernetes itself, service accounts among them
+type TokenReviewAuthenticator struct {
+	Client client.Client
+	// Audiences the token must be issued for, the API server checks its own if none are set
+	Audiences []string
+}
+
+func (a *TokenReviewAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: a.Audiences,
+		},
+	}
+	if err := a.Client.Create(ctx, review); err != nil {
+		return nil, fmt.Errorf("error reviewing token: %w", err)
+	}
+	if !review.Status.Authenticated {
+		if review.Status.Error != "" {
+			return nil, errors.New(review.Status.Error)
+		}
+		return nil, errors.New("token was not authenticated")
+	}
+
+	user := review.Status.User
+	if isReserved(user.Username) {
+		return nil, fmt.Errorf("token of reserved user %s", user.Username)
+	}
+	guid := user.UID
+	if guid == "" {
+		guid = user.Username
+	}
+	identity := &Identity{
+		Name: user.Username,
+		GUID: guid,
+	}
+	for _, group := range user.Groups {
+		// Every user is in it, Client adds it to the access reviews of the user
+		if group == authenticatedGroup {
+			continue
+		}
+		if isReserved(group) {
+			return nil, fmt.Errorf("token of user %s in reserved group %s", user.Username, group)
+		}
+		identity.Groups = append(identity.Groups, group)
+	}
+	return identity, nil
+}
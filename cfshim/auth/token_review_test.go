@@ -0,0 +1,60 @@
+package auth_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"cloudfoundry.org/cf-crd-explorations/cfshim/auth"
+)
+
+// tokenReviewingClient answers TokenReviews like the API server, it authenticates the users of its tokens
+type tokenReviewingClient struct {
+	client.Client
+	users map[string]authenticationv1.UserInfo
+}
+
+func (c *tokenReviewingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if review, ok := obj.(*authenticationv1.TokenReview); ok {
+		review.Status.User, review.Status.Authenticated = c.users[review.Spec.Token]
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestTokenReviewAuthenticator(t *testing.T) {
+	authenticator := &auth.TokenReviewAuthenticator{Client: &tokenReviewingClient{
+		Client: fake.NewClientBuilder().Build(),
+		users: map[string]authenticationv1.UserInfo{
+			"alice-token": {Username: "oidc:alice", UID: "alice-guid", Groups: []string{"system:authenticated", "oidc:developers"}},
+			"service-account-token": {
+				Username: "system:serviceaccount:default:shim",
+				Groups:   []string{"system:serviceaccounts", "system:authenticated"},
+			},
+			"masters-token": {Username: "oidc:mallory", Groups: []string{"system:masters", "system:authenticated"}},
+		},
+	}}
+
+	identity, err := authenticator.Authenticate(context.Background(), "alice-token")
+	if err != nil {
+		t.Fatalf("expected the token to be accepted, got %v", err)
+	}
+	expected := &auth.Identity{Name: "oidc:alice", GUID: "alice-guid", Groups: []string{"oidc:developers"}}
+	if !reflect.DeepEqual(identity, expected) {
+		t.Errorf("expected identity %+v, got %+v", expected, identity)
+	}
+
+	for description, token := range map[string]string{
+		"a token the API server does not accept": "unknown-token",
+		"a token of a reserved user":             "service-account-token",
+		"a token with a reserved group":          "masters-token",
+	} {
+		if _, err := authenticator.Authenticate(context.Background(), token); err == nil {
+			t.Errorf("expected %s to be rejected", description)
+		}
+	}
+}
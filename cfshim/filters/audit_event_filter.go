@@ -0,0 +1,24 @@
+package filters
+
+import (
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+)
+
+// AuditEventFields are the filters of GET /v3/audit_events, a space GUID is the namespace of the events of the space
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#list-audit-events
+var AuditEventFields = Fields{
+	"guids": {Value: func(obj interface{}) string {
+		return obj.(*appsv1alpha1.AuditEvent).Name
+	}},
+	"types": {Value: func(obj interface{}) string {
+		return obj.(*appsv1alpha1.AuditEvent).Spec.Type
+	}},
+	"target_guids": {Value: func(obj interface{}) string {
+		return obj.(*appsv1alpha1.AuditEvent).Spec.Target.GUID
+	}},
+	"space_guids": {Value: func(obj interface{}) string {
+		return obj.(*appsv1alpha1.AuditEvent).Namespace
+	}},
+	"created_ats": CreatedAtField,
+	"updated_ats": UpdatedAtField,
+}
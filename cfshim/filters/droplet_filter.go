@@ -1,26 +1,18 @@
 package filters
 
 import (
-	"fmt"
-
 	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
 )
 
-type DropletFilter struct {
-	QueryParameters map[string][]string
-}
-
-func (d *DropletFilter) Filter(input interface{}) bool {
-
-	drp, ok := input.(*appsv1alpha1.Droplet)
-	if !ok {
-		fmt.Printf("Error, could not cast filter input to droplet\n")
-		return false
-	}
-
-	if !queryParameterMatches(d.QueryParameters["guids"], drp.ObjectMeta.Name) {
-		return false
-	}
-
-	return true
+// DropletFields are the filters of GET /v3/droplets
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#list-droplets
+var DropletFields = Fields{
+	"guids": {Value: func(obj interface{}) string {
+		return obj.(*appsv1alpha1.Droplet).Name
+	}},
+	"app_guids": {Value: func(obj interface{}) string {
+		return obj.(*appsv1alpha1.Droplet).Spec.AppRef.Name
+	}},
+	"created_ats": CreatedAtField,
+	"updated_ats": UpdatedAtField,
 }
@@ -0,0 +1,182 @@
+package filters
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ListParameters are accepted on every list endpoint besides the filters of the resource, they are handled by the
+// shim handlers rather than by a Filter
+// fields takes the included resource in brackets, e.g. fields[space]=name
+var ListParameters = []string{"page", "per_page", "order_by", "label_selector", "include", "fields"}
+
+// Field is a query parameter a resource can be filtered by, with the CF name as its key in Fields
+// Multiple comma separated values match any of them, and the [not] operator excludes them instead, e.g. names[not]=a,b
+type Field struct {
+	// Value returns the value of a plain field on an object
+	Value func(obj interface{}) string
+	// Time returns the value of a timestamp field on an object, which can also be filtered with the [gt], [gte], [lt]
+	// and [lte] operators, e.g. created_ats[gt]=2021-06-01T00:00:00Z
+	// The second return value is false if the object has no such timestamp, it then only matches [not]
+	Time func(obj interface{}) (time.Time, bool)
+}
+
+// Fields declares the queryable fields of a resource
+type Fields map[string]Field
+
+// Filter matches objects against the filters in the query parameters of a request, see Fields.Parse
+type Filter struct {
+	conditions []condition
+}
+
+type condition struct {
+	field    Field
+	operator string
+	values   []string
+	times    []time.Time
+}
+
+var filterParameterPattern = regexp.MustCompile(`^([a-z_]+)(?:\[([a-z]+)\])?$`)
+
+// CreatedAtField and UpdatedAtField are the created_ats and updated_ats filters every resource has
+var (
+	CreatedAtField = Field{Time: func(obj interface{}) (time.Time, bool) {
+		return obj.(metav1.Object).GetCreationTimestamp().Time, true
+	}}
+	UpdatedAtField = Field{Time: func(obj interface{}) (time.Time, bool) {
+		updatedAt := UpdatedAt(obj.(metav1.Object))
+		if updatedAt == nil {
+			return time.Time{}, false
+		}
+		return updatedAt.Time, true
+	}}
+)
+
+// Parse builds a Filter from the query parameters, which are expected to be split on commas already
+// returns an error for unknown query parameters, unsupported operators and values that are not timestamps
+func (fields Fields) Parse(queryParameters map[string][]string) (*Filter, error) {
+	filter := &Filter{}
+	var unknown []string
+
+	for parameter, values := range queryParameters {
+		if contains(ListParameters, strings.SplitN(parameter, "[", 2)[0]) {
+			continue
+		}
+
+		matches := filterParameterPattern.FindStringSubmatch(parameter)
+		if matches == nil {
+			unknown = append(unknown, parameter)
+			continue
+		}
+		name, operator := matches[1], matches[2]
+		field, ok := fields[name]
+		if !ok {
+			unknown = append(unknown, parameter)
+			continue
+		}
+
+		c := condition{field: field, operator: operator, values: values}
+		switch operator {
+		case "", "not":
+		case "gt", "gte", "lt", "lte":
+			if field.Time == nil {
+				return nil, fmt.Errorf("The query parameter is invalid: Filter '%s' does not support the [%s] operator", name, operator)
+			}
+			if len(values) != 1 {
+				return nil, fmt.Errorf("The query parameter is invalid: Filter '%s[%s]' takes a single timestamp", name, operator)
+			}
+		default:
+			return nil, fmt.Errorf("The query parameter is invalid: Invalid operator [%s] for filter '%s'", operator, name)
+		}
+
+		if field.Time != nil {
+			for _, value := range values {
+				t, err := time.Parse(time.RFC3339, value)
+				if err != nil {
+					return nil, fmt.Errorf("The query parameter is invalid: Filter '%s' requires timestamps like 2021-06-01T00:00:00Z", name)
+				}
+				c.times = append(c.times, t)
+			}
+		}
+		filter.conditions = append(filter.conditions, c)
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("The query parameter is invalid: Unknown query parameter(s): '%s'. Valid parameters are: '%s'",
+			strings.Join(unknown, "', '"), strings.Join(fields.validParameters(), "', '"))
+	}
+	return filter, nil
+}
+
+// Matches is true if the object matches every filter
+func (f *Filter) Matches(obj interface{}) bool {
+	for _, c := range f.conditions {
+		if !c.matches(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c condition) matches(obj interface{}) bool {
+	if c.field.Time == nil {
+		return contains(c.values, c.field.Value(obj)) != (c.operator == "not")
+	}
+
+	t, ok := c.field.Time(obj)
+	if !ok {
+		return c.operator == "not"
+	}
+	switch c.operator {
+	case "gt":
+		return t.After(c.times[0])
+	case "gte":
+		return !t.Before(c.times[0])
+	case "lt":
+		return t.Before(c.times[0])
+	case "lte":
+		return !t.After(c.times[0])
+	}
+
+	equal := false
+	for _, value := range c.times {
+		equal = equal || t.Equal(value)
+	}
+	return equal != (c.operator == "not")
+}
+
+func (fields Fields) validParameters() []string {
+	valid := append([]string{}, ListParameters...)
+	for name := range fields {
+		valid = append(valid, name)
+	}
+	sort.Strings(valid)
+	return valid
+}
+
+// UpdatedAt returns the latest time in the managed fields of an object, which is when it was last updated, or nil
+func UpdatedAt(obj metav1.Object) *metav1.Time {
+	var latestTime *metav1.Time
+	for _, managedField := range obj.GetManagedFields() {
+		if managedField.Time != nil && (latestTime == nil || managedField.Time.After(latestTime.Time)) {
+			latestTime = managedField.Time
+		}
+	}
+	return latestTime
+}
+
+// contains checks if the given string exists in the list
+func contains(values []string, input string) bool {
+	for _, value := range values {
+		if value == input {
+			return true
+		}
+	}
+	return false
+}
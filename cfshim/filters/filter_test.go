@@ -0,0 +1,68 @@
+package filters_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/cfshim/filters"
+)
+
+func TestAppFields(t *testing.T) {
+	app := &appsv1alpha1.App{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "my-app-guid",
+			CreationTimestamp: metav1.NewTime(time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)),
+		},
+		Spec: appsv1alpha1.AppSpec{Name: "my-app", Type: appsv1alpha1.BuildpackLifecycle},
+	}
+
+	for _, tc := range []struct {
+		query    map[string][]string
+		expected bool
+	}{
+		{map[string][]string{}, true},
+		{map[string][]string{"names": {"other-app", "my-app"}}, true},
+		{map[string][]string{"names": {"other-app"}}, false},
+		{map[string][]string{"names[not]": {"other-app"}}, true},
+		{map[string][]string{"names[not]": {"my-app"}}, false},
+		{map[string][]string{"names": {"my-app"}, "lifecycle_type": {"docker"}}, false},
+		{map[string][]string{"created_ats[gt]": {"2021-06-01T11:59:59Z"}}, true},
+		{map[string][]string{"created_ats[lt]": {"2021-06-01T12:00:00Z"}}, false},
+		{map[string][]string{"created_ats[lte]": {"2021-06-01T12:00:00Z"}}, true},
+		{map[string][]string{"created_ats": {"2021-06-01T12:00:00Z"}}, true},
+		// Apps that were never written by a field manager have no updated_at
+		{map[string][]string{"updated_ats[gt]": {"2021-06-01T00:00:00Z"}}, false},
+		{map[string][]string{"page": {"2"}, "label_selector": {"team=a"}}, true},
+	} {
+		filter, err := filters.AppFields.Parse(tc.query)
+		if err != nil {
+			t.Errorf("%v: unexpected error %v", tc.query, err)
+			continue
+		}
+		if filter.Matches(app) != tc.expected {
+			t.Errorf("%v: expected match to be %t", tc.query, tc.expected)
+		}
+	}
+}
+
+func TestParseRejectsInvalidFilters(t *testing.T) {
+	for _, tc := range []struct {
+		query    map[string][]string
+		expected string
+	}{
+		{map[string][]string{"bogus": {"a"}}, "Unknown query parameter(s): 'bogus'"},
+		{map[string][]string{"names[gt]": {"a"}}, "does not support the [gt] operator"},
+		{map[string][]string{"names[like]": {"a"}}, "Invalid operator [like]"},
+		{map[string][]string{"created_ats[gt]": {"yesterday"}}, "requires timestamps"},
+		{map[string][]string{"created_ats[gt]": {"2021-06-01T00:00:00Z", "2021-06-02T00:00:00Z"}}, "takes a single timestamp"},
+	} {
+		_, err := filters.AppFields.Parse(tc.query)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%v: expected an error containing %q, got %v", tc.query, tc.expected, err)
+		}
+	}
+}
@@ -1,28 +1,40 @@
 package filters
 
 import (
-	"fmt"
-
 	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
 )
 
-type PackageFilter struct {
-	QueryParameters map[string][]string
+// PackageFields are the filters of GET /v3/packages and GET /v3/apps/:guid/packages
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#list-packages
+var PackageFields = Fields{
+	"guids": {Value: func(obj interface{}) string {
+		return obj.(*appsv1alpha1.Package).Name
+	}},
+	"app_guids": {Value: func(obj interface{}) string {
+		return obj.(*appsv1alpha1.Package).Spec.AppRef.Name
+	}},
+	"types": {Value: func(obj interface{}) string {
+		return string(obj.(*appsv1alpha1.Package).Spec.Type)
+	}},
+	"states": {Value: func(obj interface{}) string {
+		return DerivePackageState(obj.(*appsv1alpha1.Package))
+	}},
+	"created_ats": CreatedAtField,
+	"updated_ats": UpdatedAtField,
 }
 
-func (p *PackageFilter) Filter(input interface{}) bool {
-
-	pk, ok := input.(*appsv1alpha1.Package)
-	if !ok {
-		fmt.Printf("Error, could not cast filter input to package\n")
-		return false
+// DerivePackageState maps the package conditions to a CF API package state
+// Docker packages reference an existing image and are always READY
+func DerivePackageState(pk *appsv1alpha1.Package) string {
+	if pk.Spec.Type == appsv1alpha1.DockerPackage {
+		return "READY"
 	}
-	//fmt.Printf("AppFilter cast to App successful: %v", *app)
-
-	// Take the URL input list and compare to the field in the Package K8s CR Object
-	if !queryParameterMatches(p.QueryParameters["guids"], pk.ObjectMeta.Name) {
-		return false
+	if meta.IsStatusConditionTrue(pk.Status.Conditions, "Succeeded") &&
+		meta.IsStatusConditionTrue(pk.Status.Conditions, "Uploaded") &&
+		meta.IsStatusConditionTrue(pk.Status.Conditions, "Ready") {
+		return "READY"
+	} else {
+		return "AWAITING_UPLOAD"
 	}
-
-	return true
 }
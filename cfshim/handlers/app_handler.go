@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	corev1 "k8s.io/api/core/v1"
@@ -13,6 +14,10 @@ import (
 	"encoding/json"
 
 	cfappsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/audit"
+	"cloudfoundry.org/cf-crd-explorations/cfshim/filters"
+	"cloudfoundry.org/cf-crd-explorations/settings"
+	"cloudfoundry.org/cf-crd-explorations/tracing"
 	"github.com/google/uuid"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -41,15 +46,23 @@ func (a *AppHandler) GetAppHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	appGUID := vars["guid"]
 
+	requestParameters := r.URL.Query()
+	formatQueryParams(requestParameters)
+	include, err := includeParamsFromQuery(requestParameters, appIncludes)
+	if err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+
 	// map[string][]string
 	queryParameters := map[string][]string{
 		"guids": {appGUID},
 	}
 
 	// Use the k8s client to fetch the apps with the same metadata.name as the guid
-	matchedApps, err := getAppListFromQuery(&a.Client, queryParameters)
+	matchedApps, err := getAppListFromQuery(r.Context(), &a.Client, queryParameters)
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
@@ -59,14 +72,77 @@ func (a *AppHandler) GetAppHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// We are only printing the first element in the list for now ignoring cross-namespace guid collisions
+	included, err := a.includedForApps(r.Context(), include, matchedApps[:1])
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+
 	// Write MatchedApps to http ResponseWriter
 	w.Header().Set("Content-Type", "application/json")
-	// We are only printing the first element in the list for now ignoring cross-namespace guid collisions
-	a.ReturnFormattedResponse(w, matchedApps[0])
+	json.NewEncoder(w).Encode(GetAppResponse{
+		CFAPIPresenterAppResource: formatAppToPresenter(matchedApps[0]),
+		Included:                  included,
+	})
+}
+
+// GetAppResponse is a single App with the resources requested with include next to its own fields
+type GetAppResponse struct {
+	CFAPIPresenterAppResource
+	Included CFAPIIncluded `json:"included,omitempty"`
 }
 
 type GetListResponse struct {
-	Resources []CFAPIPresenterAppResource `json:"resources"`
+	Pagination CFAPIPagination             `json:"pagination"`
+	Resources  []CFAPIPresenterAppResource `json:"resources"`
+	Included   CFAPIIncluded               `json:"included,omitempty"`
+}
+
+// appIncludes are the resources an App can include, with the fields that can be selected of each like in the CF API
+var appIncludes = includable{
+	"space":              {"guid", "name", "relationships.organization"},
+	"space.organization": {"guid", "name"},
+}
+
+// includedForApps fetches the spaces, and the organizations of those, of the apps as requested with include
+func (a *AppHandler) includedForApps(ctx context.Context, params includeParams, apps []*cfappsv1alpha1.App) (CFAPIIncluded, error) {
+	included := newIncluder(params)
+	if !params.includes("space") && !params.includes("space.organization") {
+		return included.result(), nil
+	}
+
+	namespaces := map[string]*corev1.Namespace{}
+	for _, app := range apps {
+		namespace, fetched := namespaces[app.Namespace]
+		if !fetched {
+			namespace = &corev1.Namespace{}
+			err := a.Client.Get(ctx, types.NamespacedName{Name: app.Namespace}, namespace)
+			if apierrors.IsNotFound(err) {
+				namespace = nil
+			} else if err != nil {
+				return nil, fmt.Errorf("error fetching namespace: %v", err)
+			}
+			namespaces[app.Namespace] = namespace
+		}
+		if namespace == nil {
+			continue
+		}
+
+		if err := included.add("space", "spaces", namespace.Name, func() (interface{}, error) {
+			return formatSpaceToPresenter(namespace), nil
+		}); err != nil {
+			return nil, err
+		}
+		if org, ok := formatOrganizationToPresenter(namespace); ok {
+			if err := included.add("space.organization", "organizations", org.GUID, func() (interface{}, error) {
+				return org, nil
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return included.result(), nil
 }
 
 // ListAppsHandler takes URL query parameters and sends a request to the Kuberentes API for the list of matching apps
@@ -80,32 +156,79 @@ func (a *AppHandler) ListAppsHandler(w http.ResponseWriter, r *http.Request) {
 	// use a helper function to break comma separated values into []string
 	formatQueryParams(queryParameters)
 
+	// Unknown or malformed filters are rejected rather than ignored
+	if _, err := filters.AppFields.Parse(queryParameters); err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+	// label_selector is evaluated by the Kubernetes API rather than by the filters
+	listOptions, err := listOptionsFromQuery(queryParameters)
+	if err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+	page, err := pageParamsFromQuery(queryParameters, "created_at", "updated_at", "name")
+	if err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+	include, err := includeParamsFromQuery(queryParameters, appIncludes)
+	if err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+
 	// Apply filter to AllApps and store result in matchedApps
-	matchedApps, err := getAppListFromQuery(&a.Client, queryParameters)
+	matchedApps, err := getAppListFromQuery(r.Context(), &a.Client, queryParameters, listOptions...)
 	if err != nil {
 		// Print the error if K8s client fails
 		fmt.Printf("Error matching app: %v", err)
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
+	page.sortItems(matchedApps, func(i int) (string, string) {
+		return appOrderValue(matchedApps[i], page.OrderBy), matchedApps[i].Name
+	})
+	start, end := page.bounds(len(matchedApps))
+
 	// Convert to a list of CFAPIAppResource to match old Cloud Controller Formatting in REST response
-	formattedApps := make([]CFAPIPresenterAppResource, 0, len(matchedApps))
-	for _, app := range matchedApps {
+	formattedApps := make([]CFAPIPresenterAppResource, 0, end-start)
+	for _, app := range matchedApps[start:end] {
 		formattedApps = append(formattedApps, formatAppToPresenter(app))
 	}
+	included, err := a.includedForApps(r.Context(), include, matchedApps[start:end])
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
 
 	// Write MatchedApps to http ResponseWriter
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(GetListResponse{
-		Resources: formattedApps,
+		Pagination: page.pagination(r, len(matchedApps)),
+		Resources:  formattedApps,
+		Included:   included,
 	})
 
 }
 
+// appOrderValue returns the value of the order_by field of an App, timestamps are formatted so they order as strings
+func appOrderValue(app *cfappsv1alpha1.App, orderBy string) string {
+	switch orderBy {
+	case "name":
+		return app.Spec.Name
+	case "updated_at":
+		updatedAt, _ := getTimeLastUpdatedTimestamp(&app.ObjectMeta)
+		return updatedAt
+	default:
+		return app.CreationTimestamp.UTC().Format(time.RFC3339)
+	}
+}
+
 func (a *AppHandler) CreateAppsHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	ctx := context.Background()
+	ctx := r.Context()
 
 	var appRequest CFAPIAppResourceWithEnvVars
 	var errStrings []string
@@ -139,16 +262,16 @@ func (a *AppHandler) CreateAppsHandler(w http.ResponseWriter, r *http.Request) {
 	appname := appRequest.Name
 	if appname == "" {
 		errStrings = append(errStrings, "Name is a required entity")
-	} else {
+	} else if spaceguid != "" {
+		// App names are unique per space, the validating webhook enforces this atomically and this is only a fast path
 		queryParameters := map[string][]string{
 			"names": {appname},
 		}
-		formatQueryParams(queryParameters)
 
 		var matchedApps []*cfappsv1alpha1.App
 
-		// Apply filter to AllApps and store result in matchedApps
-		matchedApps, err := getAppListFromQuery(&a.Client, queryParameters)
+		// Apply filter to the Apps in the space and store result in matchedApps
+		matchedApps, err := getAppListFromQuery(r.Context(), &a.Client, queryParameters, client.InNamespace(spaceguid))
 		if err != nil {
 			// Print the error if K8s client fails
 			fmt.Printf("error fetching apps from query: %s\n", err)
@@ -171,12 +294,12 @@ func (a *AppHandler) CreateAppsHandler(w http.ResponseWriter, r *http.Request) {
 
 	lifecycleType := appRequest.Lifecycle.Type
 	if lifecycleType == "" {
-		lifecycleType = string(cfappsv1alpha1.BuildpackLifecycle)
+		lifecycleType = settings.GlobalDefaults.LifecycleType
 	}
 
 	lifecycleData := appRequest.Lifecycle.Data
 	if lifecycleType == string(cfappsv1alpha1.BuildpackLifecycle) && lifecycleData.Stack == "" {
-		lifecycleData.Stack = "cflinuxfs3" // TODO: This is the default in CF for VMs. What should the default stack be here?
+		lifecycleData.Stack = settings.GlobalDefaults.Stack
 	}
 	if lifecycleType == string(cfappsv1alpha1.BuildpackLifecycle) && len(lifecycleData.Buildpacks) == 0 {
 		lifecycleData.Buildpacks = []string{}
@@ -190,7 +313,7 @@ func (a *AppHandler) CreateAppsHandler(w http.ResponseWriter, r *http.Request) {
 			ReturnFormattedError(w, 404, "NotFound", err.Error(), 10000)
 		} else {
 			fmt.Printf("error fetching Namespace object: %v\n", *space)
-			ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+			returnServerError(w, err)
 		}
 		return
 	}
@@ -219,7 +342,7 @@ func (a *AppHandler) CreateAppsHandler(w http.ResponseWriter, r *http.Request) {
 		err = a.Client.Create(ctx, secretObj)
 		if err != nil {
 			fmt.Printf("error creating Secret object: %v\n", *secretObj)
-			ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+			returnServerError(w, err)
 		}
 
 		envSecret = appGUID + "-env"
@@ -234,7 +357,7 @@ func (a *AppHandler) CreateAppsHandler(w http.ResponseWriter, r *http.Request) {
 		},
 		Spec: cfappsv1alpha1.AppSpec{
 			Name:         appRequest.Name,
-			DesiredState: "STOPPED",
+			DesiredState: cfappsv1alpha1.DesiredState(settings.GlobalDefaults.DesiredState),
 			Type:         cfappsv1alpha1.LifecycleType(lifecycleType),
 			Lifecycle: cfappsv1alpha1.Lifecycle{
 				Data: cfappsv1alpha1.LifecycleData{
@@ -246,12 +369,18 @@ func (a *AppHandler) CreateAppsHandler(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
+	tracing.InjectAnnotations(r.Context(), app)
 	err = a.Client.Create(ctx, app)
+	if apierrors.IsAlreadyExists(err) {
+		ReturnFormattedError(w, 422, "CF-UniquenessError", fmt.Sprintf("App with the name '%s' already exists.", appname), 10016)
+		return
+	}
 	if err != nil {
 		fmt.Printf("error creating App object: %v\n", err)
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
+	recordAuditEvent(r, a.Client, audit.AppCreate, app, map[string]string{"name": app.Spec.Name})
 
 	a.ReturnFormattedResponse(w, app)
 }
@@ -269,7 +398,7 @@ func (a *AppHandler) UpdateAppsHandler(w http.ResponseWriter, r *http.Request) {
 	var matchedApps []*cfappsv1alpha1.App
 
 	// Apply filter to AllApps and store result in matchedApps
-	matchedApps, err := getAppListFromQuery(&a.Client, queryParameters)
+	matchedApps, err := getAppListFromQuery(r.Context(), &a.Client, queryParameters)
 	if err != nil {
 		// Print the error if K8s client fails
 		fmt.Printf("error fetching apps from query: %s\n", err)
@@ -340,7 +469,7 @@ func (a *AppHandler) UpdateAppsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	buildpacks := []string{}
-	stack := "cflinuxfs3"
+	stack := settings.GlobalDefaults.Stack
 	if len(appRequest.Lifecycle.Data.Buildpacks) != 0 {
 		buildpacks = appRequest.Lifecycle.Data.Buildpacks
 	}
@@ -353,12 +482,18 @@ func (a *AppHandler) UpdateAppsHandler(w http.ResponseWriter, r *http.Request) {
 		Stack:      stack,
 	}
 
-	err = a.Client.Update(context.Background(), matchedApp)
+	tracing.InjectAnnotations(r.Context(), matchedApp)
+	err = a.Client.Update(r.Context(), matchedApp)
+	if apierrors.IsAlreadyExists(err) {
+		ReturnFormattedError(w, 422, "CF-UniquenessError", fmt.Sprintf("App with the name '%s' already exists.", matchedApp.Spec.Name), 10016)
+		return
+	}
 	if err != nil {
 		fmt.Printf("error updating App object: %v\n", err)
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
+	recordAuditEvent(r, a.Client, audit.AppUpdate, matchedApp, map[string]string{"name": matchedApp.Spec.Name})
 
 	a.ReturnFormattedResponse(w, matchedApp)
 }
@@ -380,7 +515,7 @@ func (a *AppHandler) SetCurrentDroplet(w http.ResponseWriter, r *http.Request) {
 	}
 	formatQueryParams(queryParameters)
 
-	matchedApps, err := getAppListFromQuery(&a.Client, queryParameters)
+	matchedApps, err := getAppListFromQuery(r.Context(), &a.Client, queryParameters)
 	if err != nil {
 		fmt.Printf("error fetching apps from query: %s\n", err)
 		errorMessage = "Error fetching app"
@@ -418,7 +553,8 @@ func (a *AppHandler) SetCurrentDroplet(w http.ResponseWriter, r *http.Request) {
 	formatQueryParams(queryParameters)
 
 	var matchedDroplets []*cfappsv1alpha1.Droplet
-	matchedDroplets, err = getDropletListFromQuery(&a.Client, queryParameters)
+	// A droplet can only be assigned to an App in the same namespace
+	matchedDroplets, err = getDropletListFromQuery(r.Context(), &a.Client, queryParameters, client.InNamespace(matchedApp.Namespace))
 	if err != nil {
 		fmt.Printf("error fetching droplets from query: %s\n", err)
 		errorMessage = "Error fetching droplet"
@@ -438,16 +574,6 @@ func (a *AppHandler) SetCurrentDroplet(w http.ResponseWriter, r *http.Request) {
 	}
 	matchedDroplet := matchedDroplets[0]
 
-	if matchedApp.ObjectMeta.Namespace != matchedDroplet.ObjectMeta.Namespace {
-		fmt.Println("droplet doesn't exit in the same namespace as app")
-		errorMessage = "Unable to assign current droplet. Ensure the droplet exists and belongs to this app."
-		errorTitle = "CF-UnprocessableEntity"
-		errorCode = 10008
-		errorHeader = 422
-		ReturnFormattedError(w, errorHeader, errorTitle, errorMessage, errorCode)
-		return
-	}
-
 	if matchedApp.ObjectMeta.Name != matchedDroplet.Spec.AppRef.Name {
 		fmt.Println("Unable to assign current droplet. Ensure the droplet exists and belongs to this app.")
 		errorMessage = "Unable to assign current droplet. Ensure the droplet exists and belongs to this app."
@@ -464,7 +590,8 @@ func (a *AppHandler) SetCurrentDroplet(w http.ResponseWriter, r *http.Request) {
 		Name:       dropletRequest.Data.GUID,
 	}
 
-	err = a.Client.Update(context.Background(), matchedApp)
+	tracing.InjectAnnotations(r.Context(), matchedApp)
+	err = a.Client.Update(r.Context(), matchedApp)
 	if err != nil {
 		fmt.Printf("error updating App object: %v\n", err)
 		errorMessage = "Error updating app object"
@@ -474,6 +601,7 @@ func (a *AppHandler) SetCurrentDroplet(w http.ResponseWriter, r *http.Request) {
 		ReturnFormattedError(w, errorHeader, errorTitle, errorMessage, errorCode)
 		return
 	}
+	recordAuditEvent(r, a.Client, audit.AppDropletMapped, matchedApp, map[string]string{"droplet_guid": matchedDroplet.Name})
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(201)
@@ -513,9 +641,9 @@ func (a *AppHandler) SetAppDesiredStateHandler(w http.ResponseWriter, r *http.Re
 	}
 
 	// Use the k8s client to fetch the apps with the same metadata.name as the guid
-	matchedApps, err := getAppListFromQuery(&a.Client, queryParameters)
+	matchedApps, err := getAppListFromQuery(r.Context(), &a.Client, queryParameters)
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
@@ -533,16 +661,48 @@ func (a *AppHandler) SetAppDesiredStateHandler(w http.ResponseWriter, r *http.Re
 	if matchedApp.Spec.DesiredState != desiredState {
 		// modify the desired state of the app and re-apply it
 		matchedApp.Spec.DesiredState = desiredState
-		err = a.Client.Update(context.Background(), matchedApp)
+		tracing.InjectAnnotations(r.Context(), matchedApp)
+		err = a.Client.Update(r.Context(), matchedApp)
 		if err != nil {
 			fmt.Printf("error updating App object: %v\n", err)
 			w.WriteHeader(500)
 			return
 		}
 	}
+	// Like Cloud Controller, starting a started app is recorded too
+	if desiredState == cfappsv1alpha1.StartedState {
+		recordAuditEvent(r, a.Client, audit.AppStart, matchedApp, nil)
+	} else {
+		recordAuditEvent(r, a.Client, audit.AppStop, matchedApp, nil)
+	}
 
 	// Write MatchedApps to http ResponseWriter
 	w.Header().Set("Content-Type", "application/json")
 	// We are only printing the first element in the list for now ignoring cross-namespace guid collisions
 	a.ReturnFormattedResponse(w, matchedApp)
 }
+
+// DeleteAppHandler deletes an app along with its packages, builds, droplets and processes
+// For now, deletes the first match after searching ALL namespaces for Apps
+// DELETE /v3/apps/:guid
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#delete-an-app
+func (a *AppHandler) DeleteAppHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	appGUID := vars["guid"]
+
+	matchedApps, err := getAppListFromQuery(r.Context(), &a.Client, map[string][]string{
+		"guids": {appGUID},
+	})
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+	if len(matchedApps) < 1 {
+		ReturnFormattedError(w, 404, "CF-ResourceNotFound", "App not found", 10010)
+		return
+	}
+
+	if deleteAsync(w, r, a.Client, matchedApps[0], "app.delete") {
+		recordAuditEvent(r, a.Client, audit.AppDeleteRequest, matchedApps[0], nil)
+	}
+}
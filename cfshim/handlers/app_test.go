@@ -1,12 +1,22 @@
 package handlers_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"testing"
 
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
 	"cloudfoundry.org/cf-crd-explorations/cfshim/handlers"
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func XTestQueryParams(t *testing.T) {
@@ -77,3 +87,210 @@ func TestAppPresenterFormatting(t *testing.T) {
 	fmt.Printf("%+v\n", string(formattedJSON))
 
 }
+
+func TestListAppsLabelSelector(t *testing.T) {
+	newApp := func(guid string, appLabels map[string]string) *appsv1alpha1.App {
+		return &appsv1alpha1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: guid, Namespace: "default", Labels: appLabels},
+			Spec:       appsv1alpha1.AppSpec{Name: guid, Type: appsv1alpha1.BuildpackLifecycle},
+		}
+	}
+	kubeClient := newTestClient(
+		newApp("app-a", map[string]string{"team": "a", "env": "prod"}),
+		newApp("app-b", map[string]string{"team": "b", "env": "dev"}),
+		newApp("app-c", map[string]string{"team": "c", "env": "dev"}),
+	)
+	appHandler := &handlers.AppHandler{Client: kubeClient}
+
+	request := httptest.NewRequest("GET", "/v3/apps?"+url.Values{"label_selector": {"team in (a,b),env!=prod"}}.Encode(), nil)
+	recorder := httptest.NewRecorder()
+	appHandler.ListAppsHandler(recorder, request)
+
+	var response handlers.GetListResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Resources) != 1 || response.Resources[0].GUID != "app-b" {
+		t.Errorf("expected only app-b to match, got %+v", response.Resources)
+	}
+
+	request = httptest.NewRequest("GET", "/v3/apps?"+url.Values{"label_selector": {"team in (a"}}.Encode(), nil)
+	recorder = httptest.NewRecorder()
+	appHandler.ListAppsHandler(recorder, request)
+	if recorder.Code != 400 {
+		t.Errorf("expected an invalid label_selector to be rejected with 400, got %d", recorder.Code)
+	}
+}
+
+func TestListAppsPagination(t *testing.T) {
+	var objects []client.Object
+	for _, name := range []string{"app-b", "app-a", "app-c"} {
+		objects = append(objects, &appsv1alpha1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-guid", Namespace: "default"},
+			Spec:       appsv1alpha1.AppSpec{Name: name, Type: appsv1alpha1.BuildpackLifecycle},
+		})
+	}
+	appHandler := &handlers.AppHandler{Client: newTestClient(objects...)}
+
+	listApps := func(query string) handlers.GetListResponse {
+		recorder := httptest.NewRecorder()
+		appHandler.ListAppsHandler(recorder, httptest.NewRequest("GET", "http://api.example.org/v3/apps?"+query, nil))
+		var response handlers.GetListResponse
+		if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	response := listApps("order_by=-name&per_page=2")
+	if len(response.Resources) != 2 || response.Resources[0].Name != "app-c" || response.Resources[1].Name != "app-b" {
+		t.Errorf("expected app-c and app-b on the first page, got %+v", response.Resources)
+	}
+	if response.Pagination.TotalResults != 3 || response.Pagination.TotalPages != 2 || response.Pagination.Previous != nil {
+		t.Errorf("unexpected pagination %+v", response.Pagination)
+	}
+	if response.Pagination.Next == nil || response.Pagination.Next.Href != "http://api.example.org/v3/apps?order_by=-name&page=2&per_page=2" {
+		t.Fatalf("expected a link to the second page, got %+v", response.Pagination.Next)
+	}
+
+	response = listApps("order_by=-name&page=2&per_page=2")
+	if len(response.Resources) != 1 || response.Resources[0].Name != "app-a" {
+		t.Errorf("expected app-a on the second page, got %+v", response.Resources)
+	}
+	if response.Pagination.Next != nil || response.Pagination.Previous == nil {
+		t.Errorf("expected only a link to the previous page, got %+v", response.Pagination)
+	}
+
+	recorder := httptest.NewRecorder()
+	appHandler.ListAppsHandler(recorder, httptest.NewRequest("GET", "/v3/apps?per_page=5001", nil))
+	if recorder.Code != 400 {
+		t.Errorf("expected per_page above the maximum to be rejected with 400, got %d", recorder.Code)
+	}
+}
+
+func TestListAppsInclude(t *testing.T) {
+	appHandler := &handlers.AppHandler{Client: newTestClient(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "space-guid",
+			Labels:      map[string]string{handlers.LabelOrgGUID: "org-guid"},
+			Annotations: map[string]string{handlers.AnnotationSpaceName: "my-space", handlers.AnnotationOrgName: "my-org"},
+		}},
+		&appsv1alpha1.App{ObjectMeta: metav1.ObjectMeta{Name: "app-a-guid", Namespace: "space-guid"}, Spec: appsv1alpha1.AppSpec{Name: "app-a"}},
+		&appsv1alpha1.App{ObjectMeta: metav1.ObjectMeta{Name: "app-b-guid", Namespace: "space-guid"}, Spec: appsv1alpha1.AppSpec{Name: "app-b"}},
+	)}
+
+	recorder := httptest.NewRecorder()
+	appHandler.ListAppsHandler(recorder, httptest.NewRequest("GET", "/v3/apps?include=space.organization&fields[space]=guid,name", nil))
+	if recorder.Code != 200 {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	var response struct {
+		Included map[string][]map[string]interface{} `json:"included"`
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	expectedSpaces := []map[string]interface{}{{"guid": "space-guid", "name": "my-space"}}
+	if !reflect.DeepEqual(response.Included["spaces"], expectedSpaces) {
+		t.Errorf("expected the shared space once with only the selected fields, got %+v", response.Included["spaces"])
+	}
+	if organizations := response.Included["organizations"]; len(organizations) != 1 || organizations[0]["name"] != "my-org" {
+		t.Errorf("expected the organization of the space, got %+v", organizations)
+	}
+
+	recorder = httptest.NewRecorder()
+	appHandler.ListAppsHandler(recorder, httptest.NewRequest("GET", "/v3/apps?include=droplet", nil))
+	if recorder.Code != 400 {
+		t.Errorf("expected an include apps do not support to be rejected with 400, got %d", recorder.Code)
+	}
+}
+
+func TestDeleteApp(t *testing.T) {
+	kubeClient := newTestClient(
+		&appsv1alpha1.App{ObjectMeta: metav1.ObjectMeta{Name: "app-guid", Namespace: "default"}, Spec: appsv1alpha1.AppSpec{Name: "app"}},
+	)
+	appHandler := &handlers.AppHandler{Client: kubeClient}
+
+	recorder := httptest.NewRecorder()
+	request := mux.SetURLVars(httptest.NewRequest("DELETE", "http://api.example.org/v3/apps/app-guid", nil), map[string]string{"guid": "app-guid"})
+	appHandler.DeleteAppHandler(recorder, request)
+	if recorder.Code != 202 {
+		t.Fatalf("expected 202, got %d: %s", recorder.Code, recorder.Body)
+	}
+	if location := recorder.Header().Get("Location"); location != "http://api.example.org/v3/jobs/app.delete-app-guid" {
+		t.Errorf("expected a job location, got %q", location)
+	}
+	err := kubeClient.Get(context.Background(), types.NamespacedName{Name: "app-guid", Namespace: "default"}, &appsv1alpha1.App{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the app to be deleted, got %v", err)
+	}
+
+	job := &appsv1alpha1.Job{}
+	if err := kubeClient.Get(context.Background(), types.NamespacedName{Name: "app.delete-app-guid", Namespace: "default"}, job); err != nil {
+		t.Fatalf("expected the app.delete job to be created, got %v", err)
+	}
+	if job.Spec.Operation != "app.delete" || job.Spec.ResourceRef.Name != "app-guid" {
+		t.Errorf("expected an app.delete job of app-guid, got %+v", job.Spec)
+	}
+
+	recorder = httptest.NewRecorder()
+	appHandler.DeleteAppHandler(recorder, request)
+	if recorder.Code != 404 {
+		t.Errorf("expected deleting a missing app to return 404, got %d", recorder.Code)
+	}
+}
+
+func TestStartAppRecordsAuditEvent(t *testing.T) {
+	kubeClient := newTestClient(
+		&appsv1alpha1.App{ObjectMeta: metav1.ObjectMeta{Name: "app-guid", Namespace: "space-guid"}, Spec: appsv1alpha1.AppSpec{Name: "app"}},
+	)
+	appHandler := &handlers.AppHandler{Client: kubeClient}
+
+	recorder := httptest.NewRecorder()
+	request := mux.SetURLVars(httptest.NewRequest("POST", "/v3/apps/app-guid/actions/start", nil), map[string]string{"guid": "app-guid", "action": "start"})
+	appHandler.SetAppDesiredStateHandler(recorder, request)
+	if recorder.Code != 201 {
+		t.Fatalf("expected 201, got %d: %s", recorder.Code, recorder.Body)
+	}
+
+	events := &appsv1alpha1.AuditEventList{}
+	if err := kubeClient.List(context.Background(), events, client.InNamespace("space-guid")); err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Items) != 1 {
+		t.Fatalf("expected one audit event, got %+v", events.Items)
+	}
+	event := events.Items[0].Spec
+	if event.Type != "audit.app.start" || event.Target.GUID != "app-guid" || event.Target.Name != "app" || event.Actor.Type != "user" {
+		t.Errorf("expected the start of app by a user, got %+v", event)
+	}
+}
+
+func TestDeleteAppReplacesFinishedJob(t *testing.T) {
+	kubeClient := newTestClient(
+		&appsv1alpha1.App{ObjectMeta: metav1.ObjectMeta{Name: "app-guid", Namespace: "default"}, Spec: appsv1alpha1.AppSpec{Name: "app"}},
+		&appsv1alpha1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "app.delete-app-guid", Namespace: "default"},
+			Spec: appsv1alpha1.JobSpec{
+				Operation:   "app.delete",
+				ResourceRef: appsv1alpha1.JobResourceReference{APIVersion: appsv1alpha1.GroupVersion.String(), Kind: "App", Name: "app-guid"},
+			},
+			Status: appsv1alpha1.JobStatus{State: appsv1alpha1.JobFailedState},
+		},
+	)
+	appHandler := &handlers.AppHandler{Client: kubeClient}
+
+	recorder := httptest.NewRecorder()
+	appHandler.DeleteAppHandler(recorder, mux.SetURLVars(httptest.NewRequest("DELETE", "/v3/apps/app-guid", nil), map[string]string{"guid": "app-guid"}))
+	if recorder.Code != 202 {
+		t.Fatalf("expected 202, got %d: %s", recorder.Code, recorder.Body)
+	}
+
+	job := &appsv1alpha1.Job{}
+	if err := kubeClient.Get(context.Background(), types.NamespacedName{Name: "app.delete-app-guid", Namespace: "default"}, job); err != nil {
+		t.Fatal(err)
+	}
+	if job.Status.State != "" {
+		t.Errorf("expected the FAILED job to be replaced by a new one, got state %s", job.Status.State)
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/audit"
+	"cloudfoundry.org/cf-crd-explorations/cfshim/auth"
+	"cloudfoundry.org/cf-crd-explorations/cfshim/filters"
+)
+
+// Define the routes used in the REST endpoints
+const (
+	AuditEventsEndpoint   = "/v3/audit_events"
+	GetAuditEventEndpoint = AuditEventsEndpoint + "/{guid}"
+)
+
+type AuditEventHandler struct {
+	// This is a Kuberentes client, contains authentication and context stuff for running K8s queries
+	Client client.Client
+}
+
+type GetAuditEventListResponse struct {
+	Pagination CFAPIPagination           `json:"pagination"`
+	Resources  []CFAPIAuditEventResource `json:"resources"`
+}
+
+// ListAuditEventsHandler takes URL query parameters and sends a request to the Kuberentes API for the list of matching audit events
+// GET /v3/audit_events
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#list-audit-events
+func (a *AuditEventHandler) ListAuditEventsHandler(w http.ResponseWriter, r *http.Request) {
+	queryParameters := r.URL.Query()
+	formatQueryParams(queryParameters)
+
+	if _, err := filters.AuditEventFields.Parse(queryParameters); err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+	listOptions, err := listOptionsFromQuery(queryParameters)
+	if err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+	page, err := pageParamsFromQuery(queryParameters, "created_at", "updated_at")
+	if err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+	// Audit events have nothing to include, but include and fields are accepted by the filters so they are rejected here
+	if _, err := includeParamsFromQuery(queryParameters, includable{}); err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+
+	matchedEvents, err := getAuditEventListFromQuery(r.Context(), &a.Client, queryParameters, listOptions...)
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+
+	page.sortItems(matchedEvents, func(i int) (string, string) {
+		return auditEventOrderValue(matchedEvents[i], page.OrderBy), matchedEvents[i].Name
+	})
+	start, end := page.bounds(len(matchedEvents))
+
+	orgGUIDs := map[string]string{}
+	formattedEvents := make([]CFAPIAuditEventResource, 0, end-start)
+	for _, event := range matchedEvents[start:end] {
+		orgGUID, fetched := orgGUIDs[event.Namespace]
+		if !fetched {
+			orgGUID, err = a.orgGUIDOfNamespace(r.Context(), event.Namespace)
+			if err != nil {
+				returnServerError(w, err)
+				return
+			}
+			orgGUIDs[event.Namespace] = orgGUID
+		}
+		formattedEvents = append(formattedEvents, a.formatAuditEvent(r, event, orgGUID))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GetAuditEventListResponse{
+		Pagination: page.pagination(r, len(matchedEvents)),
+		Resources:  formattedEvents,
+	})
+}
+
+// auditEventOrderValue returns the value of the order_by field of an AuditEvent, timestamps are formatted so they order as strings
+func auditEventOrderValue(event *appsv1alpha1.AuditEvent, orderBy string) string {
+	if orderBy == "updated_at" {
+		updatedAt, _ := getTimeLastUpdatedTimestamp(&event.ObjectMeta)
+		return updatedAt
+	}
+	return event.CreationTimestamp.UTC().Format(time.RFC3339)
+}
+
+// GetAuditEventHandler is for getting a single audit event from the guid
+// For now, only outputs the first match after searching ALL namespaces for AuditEvents
+// GET /v3/audit_events/:guid
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#get-an-audit-event
+func (a *AuditEventHandler) GetAuditEventHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	eventGUID := vars["guid"]
+
+	matchedEvents, err := getAuditEventListFromQuery(r.Context(), &a.Client, map[string][]string{
+		"guids": {eventGUID},
+	})
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+	if len(matchedEvents) < 1 {
+		ReturnFormattedError(w, 404, "CF-ResourceNotFound", "Event not found", 10010)
+		return
+	}
+
+	event := matchedEvents[0]
+	orgGUID, err := a.orgGUIDOfNamespace(r.Context(), event.Namespace)
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.formatAuditEvent(r, event, orgGUID))
+}
+
+func (a *AuditEventHandler) formatAuditEvent(r *http.Request, event *appsv1alpha1.AuditEvent, orgGUID string) CFAPIAuditEventResource {
+	formattedEvent := formatAuditEventToPresenter(event, orgGUID)
+	formattedEvent.Links["self"] = CFAPILink{Href: absoluteURL(r, AuditEventsEndpoint+"/"+event.Name, nil)}
+	return formattedEvent
+}
+
+// orgGUIDOfNamespace returns the organization of the space of an audit event, or "" if it has none
+func (a *AuditEventHandler) orgGUIDOfNamespace(ctx context.Context, name string) (string, error) {
+	namespace := &corev1.Namespace{}
+	err := a.Client.Get(ctx, types.NamespacedName{Name: name}, namespace)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("error fetching namespace: %v", err)
+	}
+	return namespace.Labels[LabelOrgGUID], nil
+}
+
+// actorFromRequest returns the user that made the request
+// Without authentication, see Authenticate, every request is made by the same anonymous user
+func actorFromRequest(r *http.Request) appsv1alpha1.AuditEventActor {
+	identity := auth.IdentityFrom(r.Context())
+	if identity == nil {
+		return appsv1alpha1.AuditEventActor{
+			GUID: "anonymous",
+			Type: audit.UserActorType,
+			Name: "anonymous",
+		}
+	}
+	return appsv1alpha1.AuditEventActor{
+		GUID: identity.GUID,
+		Type: audit.UserActorType,
+		Name: identity.Name,
+	}
+}
+
+// recordAuditEvent records an audit event of the user that made the request on an App
+// Failing to record it does not fail the request, the change it describes has already been made
+func recordAuditEvent(r *http.Request, c client.Client, eventType string, app *appsv1alpha1.App, data map[string]string) {
+	// The user must not be able to write audit events on their own, the shim records them for itself
+	err := audit.Record(auth.AsShim(r.Context()), c, app.Namespace, eventType, actorFromRequest(r), audit.AppTarget(app), data)
+	if err != nil {
+		fmt.Printf("error recording audit event %s for app %s: %v\n", eventType, app.Name, err)
+	}
+}
+
+// recordAuditEventOnAppGUID is recordAuditEvent for the App a package or droplet refers to, the App is only looked up
+// for its name, so the event is still recorded if it is gone already
+func recordAuditEventOnAppGUID(r *http.Request, c client.Client, eventType string, namespace string, appGUID string, data map[string]string) {
+	app := &appsv1alpha1.App{}
+	if err := c.Get(r.Context(), types.NamespacedName{Name: appGUID, Namespace: namespace}, app); err != nil {
+		app.Name, app.Namespace = appGUID, namespace
+	}
+	recordAuditEvent(r, c, eventType, app, data)
+}
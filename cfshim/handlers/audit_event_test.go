@@ -0,0 +1,97 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/audit"
+	"cloudfoundry.org/cf-crd-explorations/cfshim/handlers"
+)
+
+func TestListAuditEvents(t *testing.T) {
+	app := &appsv1alpha1.App{ObjectMeta: metav1.ObjectMeta{Name: "app-guid", Namespace: "space-guid"}, Spec: appsv1alpha1.AppSpec{Name: "app"}}
+	newEvent := func(guid, namespace, eventType string) *appsv1alpha1.AuditEvent {
+		return &appsv1alpha1.AuditEvent{
+			ObjectMeta: metav1.ObjectMeta{Name: guid, Namespace: namespace},
+			Spec: appsv1alpha1.AuditEventSpec{
+				Type:   eventType,
+				Actor:  audit.SystemActor,
+				Target: audit.AppTarget(app),
+			},
+		}
+	}
+	auditEventHandler := &handlers.AuditEventHandler{Client: newTestClient(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "space-guid", Labels: map[string]string{handlers.LabelOrgGUID: "org-guid"}}},
+		newEvent("start-guid", "space-guid", audit.AppStart),
+		newEvent("stop-guid", "space-guid", audit.AppStop),
+		newEvent("other-space-guid", "other-space-guid", audit.AppStart),
+	)}
+
+	listAuditEvents := func(query string) handlers.GetAuditEventListResponse {
+		recorder := httptest.NewRecorder()
+		auditEventHandler.ListAuditEventsHandler(recorder, httptest.NewRequest("GET", "/v3/audit_events?"+query, nil))
+		if recorder.Code != 200 {
+			t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body)
+		}
+		var response handlers.GetAuditEventListResponse
+		if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	response := listAuditEvents("types=audit.app.start&target_guids=app-guid&space_guids=space-guid")
+	if len(response.Resources) != 1 || response.Resources[0].GUID != "start-guid" {
+		t.Fatalf("expected only the audit.app.start event of the space, got %+v", response.Resources)
+	}
+	event := response.Resources[0]
+	if event.Target.GUID != "app-guid" || event.Target.Name != "app" || event.Actor.Type != "system" {
+		t.Errorf("expected the start of app by the system, got %+v", event)
+	}
+	if event.Space == nil || event.Space.GUID != "space-guid" || event.Organization == nil || event.Organization.GUID != "org-guid" {
+		t.Errorf("expected the space and organization of the app, got %+v and %+v", event.Space, event.Organization)
+	}
+
+	if response := listAuditEvents("types=audit.app.start"); len(response.Resources) != 2 {
+		t.Errorf("expected the audit.app.start events of both spaces, got %+v", response.Resources)
+	}
+	if response := listAuditEvents("types=audit.app.delete-request"); len(response.Resources) != 0 {
+		t.Errorf("expected no audit.app.delete-request events, got %+v", response.Resources)
+	}
+}
+
+func TestGetAuditEvent(t *testing.T) {
+	auditEventHandler := &handlers.AuditEventHandler{Client: newTestClient(
+		&appsv1alpha1.AuditEvent{
+			ObjectMeta: metav1.ObjectMeta{Name: "event-guid", Namespace: "space-guid"},
+			Spec:       appsv1alpha1.AuditEventSpec{Type: audit.AppStop, Actor: audit.SystemActor},
+		},
+	)}
+	getAuditEvent := func(guid string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		auditEventHandler.GetAuditEventHandler(recorder, mux.SetURLVars(httptest.NewRequest("GET", "/v3/audit_events/"+guid, nil), map[string]string{"guid": guid}))
+		return recorder
+	}
+
+	recorder := getAuditEvent("event-guid")
+	if recorder.Code != 200 {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	var event handlers.CFAPIAuditEventResource
+	if err := json.NewDecoder(recorder.Body).Decode(&event); err != nil {
+		t.Fatal(err)
+	}
+	if event.GUID != "event-guid" || event.Type != audit.AppStop || event.Organization != nil {
+		t.Errorf("expected the audit.app.stop event without an organization, got %+v", event)
+	}
+
+	if recorder := getAuditEvent("missing-guid"); recorder.Code != 404 {
+		t.Errorf("expected a missing event to return 404, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+type CFAPIAuditEventResource struct {
+	GUID      string                `json:"guid"`
+	CreatedAt string                `json:"created_at"`
+	UpdatedAt string                `json:"updated_at"`
+	Type      string                `json:"type"`
+	Actor     CFAPIAuditEventActor  `json:"actor"`
+	Target    CFAPIAuditEventTarget `json:"target"`
+	Data      map[string]string     `json:"data"`
+	Space     *CFAPIAuditEventGUID  `json:"space"`
+	// Organization is null for events in Namespaces without the apps.cloudfoundry.org/orgGuid label
+	Organization *CFAPIAuditEventGUID `json:"organization"`
+	Links        map[string]CFAPILink `json:"links"`
+}
+
+type CFAPIAuditEventActor struct {
+	GUID string `json:"guid"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type CFAPIAuditEventTarget struct {
+	GUID string `json:"guid"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type CFAPIAuditEventGUID struct {
+	GUID string `json:"guid"`
+}
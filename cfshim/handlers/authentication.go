@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"cloudfoundry.org/cf-crd-explorations/cfshim/auth"
+)
+
+// Authenticate is a mux middleware that lets only requests with a bearer token the authenticator accepts through,
+// the handlers then act as the user the token was issued to, see auth.Client
+func Authenticate(authenticator auth.Authenticator) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parts := strings.Fields(r.Header.Get("Authorization"))
+			if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+				ReturnFormattedError(w, 401, "CF-NotAuthenticated", "Authentication error", 10002)
+				return
+			}
+
+			identity, err := authenticator.Authenticate(r.Context(), parts[1])
+			if err != nil {
+				fmt.Printf("error authenticating request: %v\n", err)
+				ReturnFormattedError(w, 401, "CF-InvalidAuthToken", "Invalid Auth Token", 1000)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -14,8 +13,13 @@ import (
 	"encoding/json"
 
 	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/audit"
+	"cloudfoundry.org/cf-crd-explorations/indexes"
+	"cloudfoundry.org/cf-crd-explorations/settings"
+	"cloudfoundry.org/cf-crd-explorations/tracing"
 	"github.com/google/uuid"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -39,15 +43,23 @@ func (b *BuildHandler) GetBuildHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	buildGUID := vars["guid"]
 
+	requestParameters := r.URL.Query()
+	formatQueryParams(requestParameters)
+	include, err := includeParamsFromQuery(requestParameters, buildIncludes)
+	if err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+
 	// map[string][]string
 	queryParameters := map[string][]string{
 		"guids": {buildGUID},
 	}
 
 	// Use the k8s client to fetch the builds with the same metadata.name as the guid
-	matchedBuilds, err := getBuildListFromQuery(&b.Client, queryParameters)
+	matchedBuilds, err := getBuildListFromQuery(r.Context(), &b.Client, queryParameters)
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
@@ -57,15 +69,42 @@ func (b *BuildHandler) GetBuildHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// We are only printing the first element in the list for now ignoring cross-namespace guid collisions
+	build := matchedBuilds[0]
+	included := newIncluder(include)
+	if err := includeRelated(r.Context(), b.Client, included, build.Namespace, map[string]string{
+		"app":     build.Spec.AppRef.Name,
+		"package": build.Spec.PackageRef.Name,
+		"droplet": build.Status.DropletReference.Name,
+	}); err != nil {
+		returnServerError(w, err)
+		return
+	}
+
 	// Write MatchedBuilds to http ResponseWriter
 	w.Header().Set("Content-Type", "application/json")
-	// We are only printing the first element in the list for now ignoring cross-namespace guid collisions
-	b.ReturnFormattedResponse(w, matchedBuilds[0])
+	json.NewEncoder(w).Encode(GetBuildResponse{
+		CFAPIBuildResource: formatBuildToPresenter(build),
+		Included:           included.result(),
+	})
+}
+
+// GetBuildResponse is a single Build with the resources requested with include next to its own fields
+type GetBuildResponse struct {
+	CFAPIBuildResource
+	Included CFAPIIncluded `json:"included,omitempty"`
+}
+
+// buildIncludes are the resources a Build can include
+var buildIncludes = includable{
+	"app":     nil,
+	"package": nil,
+	"droplet": nil,
 }
 
 func (b *BuildHandler) CreateBuildsHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	ctx := context.Background()
+	ctx := r.Context()
 
 	var buildRequest CFAPIBuildResource
 	var errStrings []string
@@ -108,12 +147,12 @@ func (b *BuildHandler) CreateBuildsHandler(w http.ResponseWriter, r *http.Reques
 
 	lifecycleType := buildRequest.Lifecycle.Type
 	if lifecycleType == "" {
-		lifecycleType = string(appsv1alpha1.BuildpackLifecycle)
+		lifecycleType = settings.GlobalDefaults.LifecycleType
 	}
 
 	lifecycleData := buildRequest.Lifecycle.Data
 	if lifecycleType == string(appsv1alpha1.BuildpackLifecycle) && lifecycleData.Stack == "" {
-		lifecycleData.Stack = "cflinuxfs3" // TODO: This is the default in CF for VMs. What should the default stack be here?
+		lifecycleData.Stack = settings.GlobalDefaults.Stack
 	}
 	if lifecycleType == string(appsv1alpha1.BuildpackLifecycle) && len(lifecycleData.Buildpacks) == 0 {
 		lifecycleData.Buildpacks = []string{}
@@ -122,7 +161,7 @@ func (b *BuildHandler) CreateBuildsHandler(w http.ResponseWriter, r *http.Reques
 	// Check if the package in the request exists
 	buildPackages := &appsv1alpha1.PackageList{}
 
-	err = b.Client.List(ctx, buildPackages, client.MatchingFields{"metadata.name": buildRequest.Package.GUID})
+	err = b.Client.List(ctx, buildPackages, client.MatchingFields{indexes.Name: buildRequest.Package.GUID})
 	// err = b.Client.Get(ctx, types.NamespacedName{Name: buildRequest.Package.GUID}, buildPackage)
 	// TODO: Check for duplicate GUIDs (oh no) in different namespaces
 	if err != nil {
@@ -130,7 +169,7 @@ func (b *BuildHandler) CreateBuildsHandler(w http.ResponseWriter, r *http.Reques
 			ReturnFormattedError(w, 404, "NotFound", err.Error(), 10000)
 		} else {
 			fmt.Printf("error fetching Namespace object: %v\n", *buildPackages)
-			ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+			returnServerError(w, err)
 		}
 		return
 	}
@@ -147,6 +186,17 @@ func (b *BuildHandler) CreateBuildsHandler(w http.ResponseWriter, r *http.Reques
 
 	buildPackage := buildPackages.Items[0]
 
+	// The Build is owned by the App of its Package, so it is garbage collected with it
+	app := &appsv1alpha1.App{}
+	err = b.Client.Get(ctx, types.NamespacedName{Namespace: buildPackage.Namespace, Name: buildPackage.Spec.AppRef.Name}, app)
+	if apierrors.IsNotFound(err) {
+		ReturnFormattedError(w, 422, "CF-UnprocessableEntity", "Invalid app. Ensure that the app exists and you have access to it.", 10008)
+		return
+	} else if err != nil {
+		returnServerError(w, err)
+		return
+	}
+
 	//generate new UUID for each create build request.
 	buildGUID := uuid.NewString()
 
@@ -162,6 +212,9 @@ func (b *BuildHandler) CreateBuildsHandler(w http.ResponseWriter, r *http.Reques
 			Namespace:   buildPackage.Namespace,
 			Labels:      buildRequest.Metadata.Labels,
 			Annotations: buildRequest.Metadata.Annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				appsv1alpha1.AppOwnerReference(app),
+			},
 		},
 		Spec: appsv1alpha1.BuildSpec{
 			Type: appsv1alpha1.LifecycleType(lifecycleType),
@@ -185,12 +238,17 @@ func (b *BuildHandler) CreateBuildsHandler(w http.ResponseWriter, r *http.Reques
 		},
 	}
 
+	tracing.InjectAnnotations(r.Context(), build)
 	err = b.Client.Create(ctx, build)
 	if err != nil {
 		fmt.Printf("error creating Build object: %v\n", err)
 		w.WriteHeader(500)
 		return
 	}
+	recordAuditEvent(r, b.Client, audit.AppBuildCreate, app, map[string]string{
+		"build_guid":   build.Name,
+		"package_guid": buildPackage.Name,
+	})
 
 	b.ReturnFormattedResponse(w, build)
 }
@@ -202,3 +260,25 @@ func (b *BuildHandler) ReturnFormattedResponse(w http.ResponseWriter, build *app
 	w.WriteHeader(201)
 	json.NewEncoder(w).Encode(formattedBuild)
 }
+
+// DeleteBuildHandler deletes a build, its droplet is kept
+// For now, deletes the first match after searching ALL namespaces for Builds
+// DELETE /v3/builds/:guid
+func (b *BuildHandler) DeleteBuildHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	buildGUID := vars["guid"]
+
+	matchedBuilds, err := getBuildListFromQuery(r.Context(), &b.Client, map[string][]string{
+		"guids": {buildGUID},
+	})
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+	if len(matchedBuilds) < 1 {
+		ReturnFormattedError(w, 404, "CF-ResourceNotFound", "Build not found", 10010)
+		return
+	}
+
+	deleteAsync(w, r, b.Client, matchedBuilds[0], "build.delete")
+}
@@ -3,6 +3,7 @@ package handlers
 type CFAPIBuildResource struct {
 	GUID          string                  `json:"guid"`
 	State         string                  `json:"state"`
+	Error         *string                 `json:"error"`
 	CreatedAt     string                  `json:"created_at"`
 	UpdatedAt     string                  `json:"updated_at"`
 	Lifecycle     CFAPILifecycle          `json:"lifecycle,omitempty"`
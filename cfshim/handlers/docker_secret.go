@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// dockerConfigJSON is the format of the .dockerconfigjson key in a kubernetes.io/dockerconfigjson Secret
+// See: https://kubernetes.io/docs/concepts/configuration/secret/#docker-config-secrets
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// DockerRegistryHost parses an image reference and returns the key kubelet and the kpack keychain use to look up its credentials
+func DockerRegistryHost(image string) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	registryHost := ref.Context().RegistryStr()
+	// Docker Hub credentials are conventionally stored under the legacy v1 index URL
+	if registryHost == name.DefaultRegistry {
+		return authn.DefaultAuthKey, nil
+	}
+	return registryHost, nil
+}
+
+// generateDockerConfigJSON builds the .dockerconfigjson data holding a single set of registry credentials
+func generateDockerConfigJSON(registryHost, username, password string) ([]byte, error) {
+	return json.Marshal(dockerConfigJSON{
+		Auths: map[string]dockerConfigEntry{
+			registryHost: {
+				Username: username,
+				Password: password,
+				Auth:     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+			},
+		},
+	})
+}
+
+// DockerCredentialsFromSecret returns the username and password stored in a dockerconfigjson Secret for the registry of the given image
+// ok is false if the Secret is not a dockerconfigjson Secret or has no credentials for that registry
+func DockerCredentialsFromSecret(secret *corev1.Secret, image string) (username string, password string, ok bool) {
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		return "", "", false
+	}
+
+	registryHost, err := DockerRegistryHost(image)
+	if err != nil {
+		return "", "", false
+	}
+
+	var config dockerConfigJSON
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+		return "", "", false
+	}
+
+	for key, entry := range config.Auths {
+		if normalizeRegistryKey(key) != normalizeRegistryKey(registryHost) {
+			continue
+		}
+		if entry.Username == "" && entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return "", "", false
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) != 2 {
+				return "", "", false
+			}
+			return parts[0], parts[1], true
+		}
+		return entry.Username, entry.Password, true
+	}
+	return "", "", false
+}
+
+// normalizeRegistryKey strips the scheme and path so "https://index.docker.io/v1/" and "index.docker.io" compare equal
+func normalizeRegistryKey(key string) string {
+	key = strings.TrimPrefix(key, "https://")
+	key = strings.TrimPrefix(key, "http://")
+	return strings.SplitN(key, "/", 2)[0]
+}
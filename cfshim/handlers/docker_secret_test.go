@@ -0,0 +1,43 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"cloudfoundry.org/cf-crd-explorations/cfshim/handlers"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDockerRegistryHost(t *testing.T) {
+	cases := map[string]string{
+		"relintdockerhubpushbot/dora":          "https://index.docker.io/v1/",
+		"gcr.io/my-project/my-image:latest":    "gcr.io",
+		"registry.example.com:5000/team/image": "registry.example.com:5000",
+	}
+	for image, expected := range cases {
+		host, err := handlers.DockerRegistryHost(image)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", image, err)
+		}
+		if host != expected {
+			t.Errorf("expected registry host %s for %s, got %s", expected, image, host)
+		}
+	}
+}
+
+func TestDockerCredentialsFromSecret(t *testing.T) {
+	secret := &corev1.Secret{
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"https://index.docker.io/v1/":{"auth":"dXNlcjpwYXNz"}}}`),
+		},
+	}
+
+	username, password, ok := handlers.DockerCredentialsFromSecret(secret, "docker.io/library/nginx")
+	if !ok || username != "user" || password != "pass" {
+		t.Errorf("expected user/pass credentials, got %q/%q (ok=%v)", username, password, ok)
+	}
+
+	if _, _, ok := handlers.DockerCredentialsFromSecret(secret, "gcr.io/my-project/my-image"); ok {
+		t.Errorf("expected no credentials for a different registry")
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"cloudfoundry.org/cf-crd-explorations/audit"
+)
+
+// Define the routes used in the REST endpoints
+const (
+	DropletsEndpoint   = "/v3/droplets"
+	GetDropletEndpoint = DropletsEndpoint + "/{guid}"
+)
+
+type DropletHandler struct {
+	// This is a Kuberentes client, contains authentication and context stuff for running K8s queries
+	Client client.Client
+}
+
+// GetDropletResponse is a single Droplet with the resources requested with include next to its own fields
+type GetDropletResponse struct {
+	CFAPIDropletResource
+	Included CFAPIIncluded `json:"included,omitempty"`
+}
+
+// dropletIncludes are the resources a Droplet can include
+var dropletIncludes = includable{
+	"app":   nil,
+	"build": nil,
+}
+
+// GetDropletHandler is for getting a single droplet from the guid
+// For now, only outputs the first match after searching ALL namespaces for Droplets
+// GET /v3/droplets/:guid
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#get-a-droplet
+func (d *DropletHandler) GetDropletHandler(w http.ResponseWriter, r *http.Request) {
+	//Fetch the {guid} value from URL using gorilla mux
+	vars := mux.Vars(r)
+	dropletGUID := vars["guid"]
+
+	requestParameters := r.URL.Query()
+	formatQueryParams(requestParameters)
+	include, err := includeParamsFromQuery(requestParameters, dropletIncludes)
+	if err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+
+	matchedDroplets, err := getDropletListFromQuery(r.Context(), &d.Client, map[string][]string{
+		"guids": {dropletGUID},
+	})
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+
+	if len(matchedDroplets) < 1 {
+		ReturnFormattedError(w, 404, "CF-ResourceNotFound", "Droplet not found", 10010)
+		return
+	}
+
+	// We are only printing the first element in the list for now ignoring cross-namespace guid collisions
+	droplet := matchedDroplets[0]
+	included := newIncluder(include)
+	if err := includeRelated(r.Context(), d.Client, included, droplet.Namespace, map[string]string{
+		"app":   droplet.Spec.AppRef.Name,
+		"build": droplet.Spec.BuildRef.Name,
+	}); err != nil {
+		returnServerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GetDropletResponse{
+		CFAPIDropletResource: formatDropletToPresenter(droplet),
+		Included:             included.result(),
+	})
+}
+
+// DeleteDropletHandler deletes a droplet
+// For now, deletes the first match after searching ALL namespaces for Droplets
+// DELETE /v3/droplets/:guid
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#delete-a-droplet
+func (d *DropletHandler) DeleteDropletHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	dropletGUID := vars["guid"]
+
+	matchedDroplets, err := getDropletListFromQuery(r.Context(), &d.Client, map[string][]string{
+		"guids": {dropletGUID},
+	})
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+	if len(matchedDroplets) < 1 {
+		ReturnFormattedError(w, 404, "CF-ResourceNotFound", "Droplet not found", 10010)
+		return
+	}
+
+	droplet := matchedDroplets[0]
+	if deleteAsync(w, r, d.Client, droplet, "droplet.delete") {
+		recordAuditEventOnAppGUID(r, d.Client, audit.AppDropletDelete, droplet.Namespace, droplet.Spec.AppRef.Name, map[string]string{"droplet_guid": droplet.Name})
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+type CFAPIDropletResource struct {
+	GUID              string                  `json:"guid"`
+	State             string                  `json:"state"`
+	Error             *string                 `json:"error"`
+	Lifecycle         CFAPIDropletLifecycle   `json:"lifecycle"`
+	ExecutionMetadata string                  `json:"execution_metadata"`
+	ProcessTypes      map[string]string       `json:"process_types"`
+	Checksum          *CFAPIPresenterChecksum `json:"checksum"`
+	Buildpacks        []string                `json:"buildpacks"`
+	Stack             *string                 `json:"stack"`
+	Image             *string                 `json:"image"`
+	CreatedAt         string                  `json:"created_at"`
+	UpdatedAt         string                  `json:"updated_at"`
+	Relationships     CFAPIBuildRelationships `json:"relationships"`
+	Links             map[string]CFAPILink    `json:"links"`
+	Metadata          CFAPIMetadata           `json:"metadata"`
+}
+
+type CFAPIDropletLifecycle struct {
+	Type string                 `json:"type"`
+	Data map[string]interface{} `json:"data"`
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+)
+
+// CFAPIIncluded holds the related resources requested with include, by their plural CF resource type
+type CFAPIIncluded map[string][]interface{}
+
+// includable declares the resources an endpoint can include, by their path from the presented resource
+// The values are the fields that can be selected from that resource with fields[path], nil if it cannot be narrowed
+type includable map[string][]string
+
+// includeParams are the include and fields query parameters of a request
+// See: https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#include
+// and https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#fields-parameter
+type includeParams struct {
+	Include []string
+	Fields  map[string][]string
+}
+
+// includeParamsFromQuery reads include and fields[...], resources are the includes the endpoint supports
+// returns an error for resources or fields the endpoint does not support
+func includeParamsFromQuery(queryParams map[string][]string, resources includable) (includeParams, error) {
+	params := includeParams{Fields: map[string][]string{}}
+
+	for _, path := range queryParams["include"] {
+		if _, ok := resources[path]; !ok && len(resources) == 0 {
+			return params, fmt.Errorf("The query parameter is invalid: Invalid included resource: '%s'. Nothing can be included", path)
+		} else if !ok {
+			return params, fmt.Errorf("The query parameter is invalid: Invalid included resource: '%s'. Valid included resources are: '%s'",
+				path, strings.Join(resources.paths(), "', '"))
+		}
+		params.Include = append(params.Include, path)
+	}
+
+	for parameter, values := range queryParams {
+		if !strings.HasPrefix(parameter, "fields[") {
+			continue
+		}
+		path := strings.TrimSuffix(strings.TrimPrefix(parameter, "fields["), "]")
+		allowed := resources[path]
+		if len(allowed) == 0 || !strings.HasSuffix(parameter, "]") {
+			return params, fmt.Errorf("The query parameter is invalid: Fields cannot be selected for '%s'", path)
+		}
+		for _, field := range values {
+			if !contains(allowed, field) {
+				return params, fmt.Errorf("The query parameter is invalid: Invalid field '%s' for '%s'. Valid fields are: '%s'",
+					field, path, strings.Join(allowed, "', '"))
+			}
+		}
+		params.Fields[path] = values
+	}
+
+	return params, nil
+}
+
+// includes is true if the resource at path was requested, either directly, through a nested resource
+// (include=space.organization also includes the space) or by selecting its fields
+func (p includeParams) includes(path string) bool {
+	for _, include := range p.Include {
+		if include == path || strings.HasPrefix(include, path+".") {
+			return true
+		}
+	}
+	_, ok := p.Fields[path]
+	return ok
+}
+
+func (resources includable) paths() []string {
+	var paths []string
+	for path := range resources {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// includer collects the included resources of a response, presenting each of them once
+type includer struct {
+	params   includeParams
+	included CFAPIIncluded
+	seen     map[string]bool
+}
+
+func newIncluder(params includeParams) *includer {
+	return &includer{
+		params:   params,
+		included: CFAPIIncluded{},
+		seen:     map[string]bool{},
+	}
+}
+
+// add includes the resource with the guid if it was requested at path and has not been added yet
+// present fetches and presents the resource, it returns nil if the resource no longer exists so it is left out
+func (i *includer) add(path string, resourceType string, guid string, present func() (interface{}, error)) error {
+	key := resourceType + "/" + guid
+	if guid == "" || !i.params.includes(path) || i.seen[key] {
+		return nil
+	}
+	i.seen[key] = true
+
+	resource, err := present()
+	if err != nil || resource == nil {
+		return err
+	}
+	if fields, ok := i.params.Fields[path]; ok {
+		resource, err = selectFields(resource, fields)
+		if err != nil {
+			return err
+		}
+	}
+	i.included[resourceType] = append(i.included[resourceType], resource)
+	return nil
+}
+
+// result returns the included resources, or nil if nothing was included so the block is left out of the response
+func (i *includer) result() CFAPIIncluded {
+	if len(i.included) == 0 {
+		return nil
+	}
+	return i.included
+}
+
+// includeRelated adds the App, Package, Droplet and Build a resource refers to, refs maps their include path to their
+// GUID in the namespace of the resource
+// References that are not set yet or objects that no longer exist are left out
+func includeRelated(ctx context.Context, c client.Client, included *includer, namespace string, refs map[string]string) error {
+	for _, path := range []string{"app", "package", "droplet", "build"} {
+		guid, ok := refs[path]
+		if !ok {
+			continue
+		}
+		err := included.add(path, path+"s", guid, func() (interface{}, error) {
+			resource, err := presentRelated(ctx, c, path, types.NamespacedName{Namespace: namespace, Name: guid})
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			} else if err != nil {
+				return nil, fmt.Errorf("error fetching %s: %v", path, err)
+			}
+			return resource, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func presentRelated(ctx context.Context, c client.Client, path string, key types.NamespacedName) (interface{}, error) {
+	switch path {
+	case "app":
+		app := &appsv1alpha1.App{}
+		if err := c.Get(ctx, key, app); err != nil {
+			return nil, err
+		}
+		return formatAppToPresenter(app), nil
+	case "package":
+		pk := &appsv1alpha1.Package{}
+		if err := c.Get(ctx, key, pk); err != nil {
+			return nil, err
+		}
+		return formatPresenterPackageResponse(pk), nil
+	case "droplet":
+		droplet := &appsv1alpha1.Droplet{}
+		if err := c.Get(ctx, key, droplet); err != nil {
+			return nil, err
+		}
+		return formatDropletToPresenter(droplet), nil
+	default:
+		build := &appsv1alpha1.Build{}
+		if err := c.Get(ctx, key, build); err != nil {
+			return nil, err
+		}
+		return formatBuildToPresenter(build), nil
+	}
+}
+
+// selectFields narrows a presented resource down to the JSON fields requested with fields[...]
+// A nested field such as relationships.organization keeps only that field of its parent
+func selectFields(resource interface{}, fields []string) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	all := map[string]interface{}{}
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		return nil, err
+	}
+
+	selected := map[string]interface{}{}
+	for _, field := range fields {
+		parts := strings.SplitN(field, ".", 2)
+		value, ok := all[parts[0]]
+		if !ok {
+			continue
+		}
+		if len(parts) == 1 {
+			selected[field] = value
+			continue
+		}
+		parent, _ := selected[parts[0]].(map[string]interface{})
+		if parent == nil {
+			parent = map[string]interface{}{}
+			selected[parts[0]] = parent
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			parent[parts[1]] = nested[parts[1]]
+		}
+	}
+	return selected, nil
+}
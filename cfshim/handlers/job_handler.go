@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/indexes"
+)
+
+// Define the routes used in the REST endpoints
+const (
+	JobsEndpoint   = "/v3/jobs"
+	GetJobEndpoint = JobsEndpoint + "/{guid}"
+)
+
+type JobHandler struct {
+	// This is a Kuberentes client, contains authentication and context stuff for running K8s queries
+	Client client.Client
+}
+
+// GetJobHandler is for polling an asynchronous operation, the Location of a 202 Accepted response
+// For now, only outputs the first match after searching ALL namespaces for Jobs
+// GET /v3/jobs/:guid
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#get-a-job
+func (j *JobHandler) GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobGUID := vars["guid"]
+
+	matchedJobs := &appsv1alpha1.JobList{}
+	err := j.Client.List(r.Context(), matchedJobs, client.MatchingFields{indexes.Name: jobGUID})
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+
+	var job *appsv1alpha1.Job
+	for i := range matchedJobs.Items {
+		// The fake client used in tests ignores field selectors
+		if matchedJobs.Items[i].Name == jobGUID {
+			job = &matchedJobs.Items[i]
+			break
+		}
+	}
+	if job == nil {
+		ReturnFormattedError(w, 404, "CF-ResourceNotFound", "Job not found", 10010)
+		return
+	}
+
+	formattedJob := formatJobToPresenter(job)
+	formattedJob.Links["self"] = CFAPILink{Href: absoluteURL(r, JobsEndpoint+"/"+job.Name, nil)}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(formattedJob)
+}
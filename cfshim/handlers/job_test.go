@@ -0,0 +1,47 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/cfshim/handlers"
+)
+
+func TestGetJob(t *testing.T) {
+	jobHandler := &handlers.JobHandler{Client: newTestClient(
+		&appsv1alpha1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "app.delete-app-guid", Namespace: "default"},
+			Spec:       appsv1alpha1.JobSpec{Operation: "app.delete"},
+			Status:     appsv1alpha1.JobStatus{State: appsv1alpha1.JobProcessingState},
+		},
+	)}
+	getJob := func(guid string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		jobHandler.GetJobHandler(recorder, mux.SetURLVars(httptest.NewRequest("GET", "http://api.example.org/v3/jobs/"+guid, nil), map[string]string{"guid": guid}))
+		return recorder
+	}
+
+	recorder := getJob("app.delete-app-guid")
+	if recorder.Code != 200 {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	var job handlers.CFAPIJobResource
+	if err := json.NewDecoder(recorder.Body).Decode(&job); err != nil {
+		t.Fatal(err)
+	}
+	if job.GUID != "app.delete-app-guid" || job.Operation != "app.delete" || job.State != "PROCESSING" {
+		t.Errorf("expected a PROCESSING app.delete job, got %+v", job)
+	}
+	if job.Links["self"].Href != "http://api.example.org/v3/jobs/app.delete-app-guid" {
+		t.Errorf("expected a self link, got %+v", job.Links)
+	}
+
+	if recorder := getJob("missing-guid"); recorder.Code != 404 {
+		t.Errorf("expected a missing job to return 404, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,16 @@
+package handlers
+
+type CFAPIJobResource struct {
+	GUID      string               `json:"guid"`
+	CreatedAt string               `json:"created_at"`
+	UpdatedAt string               `json:"updated_at"`
+	Operation string               `json:"operation"`
+	State     string               `json:"state"`
+	Errors    []CFAPIError         `json:"errors"`
+	Warnings  []CFAPIJobWarning    `json:"warnings"`
+	Links     map[string]CFAPILink `json:"links"`
+}
+
+type CFAPIJobWarning struct {
+	Detail string `json:"detail"`
+}
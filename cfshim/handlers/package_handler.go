@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,12 +10,18 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/audit"
 	"cloudfoundry.org/cf-crd-explorations/cfshim/filters"
 	"cloudfoundry.org/cf-crd-explorations/settings"
+	"cloudfoundry.org/cf-crd-explorations/tracing"
 	"github.com/buildpacks/pack/pkg/archive"
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
@@ -32,9 +40,11 @@ import (
 
 // Define the routes used in the REST endpoints
 const (
-	PackageEndpoint       = "/v3/packages"
-	UploadPackageEndpoint = "/v3/packages/{guid}/upload"
-	GetPackageEndpoint    = PackageEndpoint + "/{guid}"
+	PackageEndpoint         = "/v3/packages"
+	UploadPackageEndpoint   = "/v3/packages/{guid}/upload"
+	GetPackageEndpoint      = PackageEndpoint + "/{guid}"
+	DownloadPackageEndpoint = GetPackageEndpoint + "/download"
+	AppPackagesEndpoint     = GetAppEndpoint + "/packages"
 )
 
 type PackageHandler struct {
@@ -43,32 +53,105 @@ type PackageHandler struct {
 	KeychainFactory registry.KeychainFactory
 }
 
-func (p *PackageHandler) getPackageHelper(queryParameters map[string][]string) ([]*appsv1alpha1.Package, error) {
-	var filter Filter = &filters.PackageFilter{
-		QueryParameters: queryParameters,
+func (p *PackageHandler) ReturnFormattedResponse(w http.ResponseWriter, formattedPackage *CFAPIPresenterPackageResource) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+	json.NewEncoder(w).Encode(*formattedPackage)
+}
+
+type GetPackageListResponse struct {
+	Pagination CFAPIPagination                 `json:"pagination"`
+	Resources  []CFAPIPresenterPackageResource `json:"resources"`
+}
+
+// ListPackagesHandler takes URL query parameters and sends a request to the Kuberentes API for the list of matching packages
+// GET /v3/packages
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#list-packages
+func (p *PackageHandler) ListPackagesHandler(w http.ResponseWriter, r *http.Request) {
+	queryParameters := r.URL.Query()
+	formatQueryParams(queryParameters)
+
+	p.returnPackageList(w, r, queryParameters)
+}
+
+// ListAppPackagesHandler lists the packages that belong to a single app, accepting the same filters as ListPackagesHandler
+// GET /v3/apps/:guid/packages
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#list-packages-for-an-app
+func (p *PackageHandler) ListAppPackagesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	appGUID := vars["guid"]
+
+	matchedApps, err := getAppListFromQuery(r.Context(), &p.Client, map[string][]string{
+		"guids": {appGUID},
+	})
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+	if len(matchedApps) == 0 {
+		ReturnFormattedError(w, 404, "CF-ResourceNotFound", fmt.Sprintf("App with guid %s not found", appGUID), 10010)
+		return
 	}
 
-	AllPackages := &appsv1alpha1.PackageList{}
-	err := p.Client.List(context.Background(), AllPackages)
+	queryParameters := r.URL.Query()
+	formatQueryParams(queryParameters)
+	queryParameters["app_guids"] = []string{appGUID}
+
+	p.returnPackageList(w, r, queryParameters)
+}
+
+func (p *PackageHandler) returnPackageList(w http.ResponseWriter, r *http.Request, queryParameters map[string][]string) {
+	if _, err := filters.PackageFields.Parse(queryParameters); err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+	listOptions, err := listOptionsFromQuery(queryParameters)
+	if err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+	page, err := pageParamsFromQuery(queryParameters, "created_at", "updated_at")
 	if err != nil {
-		return nil, fmt.Errorf("error fetching package: %v", err)
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
+	}
+	// Packages have nothing to include, but include and fields are accepted by the filters so they are rejected here
+	if _, err := includeParamsFromQuery(queryParameters, includable{}); err != nil {
+		ReturnFormattedError(w, 400, "CF-BadQueryParameter", err.Error(), 10005)
+		return
 	}
 
-	var matchedPackages []*appsv1alpha1.Package
-	//fmt.Printf("%v\n", matchedPackages)
-	for i, _ := range AllPackages.Items {
-		if filter.Filter(&AllPackages.Items[i]) {
-			matchedPackages = append(matchedPackages, &AllPackages.Items[i])
-		}
+	matchedPackages, err := getPackagesListFromQuery(r.Context(), &p.Client, queryParameters, listOptions...)
+	if err != nil {
+		fmt.Printf("Error matching package: %v", err)
+		returnServerError(w, err)
+		return
 	}
 
-	return matchedPackages, nil
-}
+	page.sortItems(matchedPackages, func(i int) (string, string) {
+		return packageOrderValue(matchedPackages[i], page.OrderBy), matchedPackages[i].Name
+	})
+	start, end := page.bounds(len(matchedPackages))
+
+	formattedPackages := make([]CFAPIPresenterPackageResource, 0, end-start)
+	for _, pk := range matchedPackages[start:end] {
+		formattedPackages = append(formattedPackages, formatPresenterPackageResponse(pk))
+	}
 
-func (p *PackageHandler) ReturnFormattedResponse(w http.ResponseWriter, formattedPackage *CFAPIPresenterPackageResource) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(201)
-	json.NewEncoder(w).Encode(*formattedPackage)
+	json.NewEncoder(w).Encode(GetPackageListResponse{
+		Pagination: page.pagination(r, len(matchedPackages)),
+		Resources:  formattedPackages,
+	})
+}
+
+// packageOrderValue returns the value of the order_by field of a Package, timestamps are formatted so they order as strings
+func packageOrderValue(pk *appsv1alpha1.Package, orderBy string) string {
+	if orderBy == "updated_at" {
+		updatedAt, _ := getTimeLastUpdatedTimestamp(&pk.ObjectMeta)
+		return updatedAt
+	}
+	return pk.CreationTimestamp.UTC().Format(time.RFC3339)
 }
 
 // GetPackageHandler is for getting a single package from the guid
@@ -84,7 +167,7 @@ func (p *PackageHandler) GetPackageHandler(w http.ResponseWriter, r *http.Reques
 		"guids": {packageGUID},
 	}
 	// Convert to a list of CFAPIAppResource to match old Cloud Controller Formatting in REST response
-	matchedPackages, err := p.getPackageHelper(queryParameters)
+	matchedPackages, err := getPackagesListFromQuery(r.Context(), &p.Client, queryParameters)
 	if err != nil {
 		fmt.Printf("error fetching the package: %s\n", err)
 		w.WriteHeader(500)
@@ -99,20 +182,12 @@ func (p *PackageHandler) GetPackageHandler(w http.ResponseWriter, r *http.Reques
 	firstMatchedPackage := matchedPackages[0]
 	formattedMatchingPackage := formatPresenterPackageResponse(firstMatchedPackage)
 
-	// for Docker packages we need to look up if it has a secret for username & password?
-	// TODO: Standardize on the secrets approach - Staging flow uses image pull secrets which are different from the Package create API right now
-	if formattedMatchingPackage.Type == "docker" {
-		packageSecret, err := p.getSecretHelper(firstMatchedPackage.Namespace, generatePackageSecretName(firstMatchedPackage.Name))
-		//fmt.Printf("Package secret was: %+v \nError: %v", packageSecret, err)
+	// for Docker packages we need to look up if it has a secret for username & password
+	if formattedMatchingPackage.Type == "docker" && len(firstMatchedPackage.Spec.Source.Registry.ImagePullSecrets) > 0 {
+		packageSecret, err := p.getSecretHelper(r.Context(), firstMatchedPackage.Namespace, firstMatchedPackage.Spec.Source.Registry.ImagePullSecrets[0].Name)
 		if err == nil {
-			// get the username field and decode it
-			if usernameEncoded, exists := packageSecret.Data["username"]; exists {
-				formattedMatchingPackage.Data.Username = string(usernameEncoded)
-			}
-
-			// get the password field and see if it exists
-			if _, exists := packageSecret.Data["password"]; exists {
-				formattedMatchingPackage.Data.Password = "***"
+			if username, _, ok := DockerCredentialsFromSecret(packageSecret, firstMatchedPackage.Spec.Source.Registry.Image); ok {
+				updateDockerPackageResponse(&formattedMatchingPackage, username)
 			}
 		}
 	}
@@ -122,10 +197,37 @@ func (p *PackageHandler) GetPackageHandler(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(formattedMatchingPackage)
 }
 
+// DeletePackageHandler deletes a package, the PackageReconciler deletes its docker secret and uploaded bits
+// For now, deletes the first match after searching ALL namespaces for Packages
+// DELETE /v3/packages/:guid
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#delete-a-package
+func (p *PackageHandler) DeletePackageHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	packageGUID := vars["guid"]
+
+	matchedPackages, err := getPackagesListFromQuery(r.Context(), &p.Client, map[string][]string{
+		"guids": {packageGUID},
+	})
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+	if len(matchedPackages) < 1 {
+		ReturnFormattedError(w, 404, "CF-ResourceNotFound", "Package not found", 10010)
+		return
+	}
+
+	pk := matchedPackages[0]
+	if deleteAsync(w, r, p.Client, pk, "package.delete") {
+		recordAuditEventOnAppGUID(r, p.Client, audit.AppPackageDelete, pk.Namespace, pk.Spec.AppRef.Name, map[string]string{"package_guid": pk.Name})
+	}
+}
+
 // getSecretHelper returns a secret given its namespace and name. Returns nil and an error if not found.
-func (p *PackageHandler) getSecretHelper(namespace, name string) (*corev1.Secret, error) {
+// The secret is read as the caller of the request of ctx, who may not read secrets where they may read packages
+func (p *PackageHandler) getSecretHelper(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
 	secret := &corev1.Secret{}
-	err := p.Client.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, secret)
+	err := p.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			return nil, err
@@ -150,7 +252,7 @@ func (p *PackageHandler) CreatePackageHandler(w http.ResponseWriter, r *http.Req
 	queryParams := map[string][]string{
 		"guids": {packageRequest.Relationships.App.Data.GUID},
 	}
-	matchedApps, err := getAppListFromQuery(&p.Client, queryParams)
+	matchedApps, err := getAppListFromQuery(r.Context(), &p.Client, queryParams)
 	if err != nil {
 		// Print the error if K8s client fails
 		w.WriteHeader(500)
@@ -172,6 +274,9 @@ func (p *PackageHandler) CreatePackageHandler(w http.ResponseWriter, r *http.Req
 			Name:      packageGUID,
 			Namespace: namespace,
 			Labels:    map[string]string{LabelAppGUID: app.Name},
+			OwnerReferences: []metav1.OwnerReference{
+				appsv1alpha1.AppOwnerReference(app),
+			},
 		},
 		Spec: appsv1alpha1.PackageSpec{
 			Type: appsv1alpha1.PackageType(packageRequest.Type),
@@ -189,6 +294,14 @@ func (p *PackageHandler) CreatePackageHandler(w http.ResponseWriter, r *http.Req
 			return
 		}
 
+		// The registry host is needed to key the credentials, so the image must be a valid reference
+		registryHost, err := DockerRegistryHost(packageRequest.Data.Image)
+		if err != nil {
+			errorMessage := fmt.Sprintf("Data Image must be a valid docker image reference: %v", err)
+			ReturnFormattedError(w, 422, "CF-UnprocessableEntity", errorMessage, 10008)
+			return
+		}
+
 		// Add the docker image to the package spec
 		pk.Spec.Source = appsv1alpha1.PackageSource{
 			Registry: appsv1alpha1.Registry{
@@ -200,18 +313,27 @@ func (p *PackageHandler) CreatePackageHandler(w http.ResponseWriter, r *http.Req
 		if packageRequest.Data.Username != nil && packageRequest.Data.Password != nil {
 			secretName := generatePackageSecretName(packageGUID)
 
+			dockerConfig, err := generateDockerConfigJSON(registryHost, *packageRequest.Data.Username, *packageRequest.Data.Password)
+			if err != nil {
+				returnServerError(w, err)
+				return
+			}
+
 			// Only create a secret if type is Docker and we have a username + password in packageRequest
+			// The dockerconfigjson type lets kubelet and the kpack keychain use it directly as an image pull secret
 			secretObj := &corev1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      generatePackageSecretName(packageGUID),
+					Name:      secretName,
 					Namespace: namespace,
 				},
-				StringData: map[string]string{"username": *packageRequest.Data.Username, "password": *packageRequest.Data.Password},
+				Type: corev1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{corev1.DockerConfigJsonKey: dockerConfig},
 			}
-			err = p.Client.Create(context.Background(), secretObj)
+			err = p.Client.Create(r.Context(), secretObj)
 			if err != nil {
 				fmt.Printf("error creating docker package Secret object: %v\n", err)
-				w.WriteHeader(500)
+				returnServerError(w, err)
+				return
 			}
 			// Add the secret details to our desired Package that we will create
 			pk.Spec.Source.Registry.ImagePullSecrets = []corev1.LocalObjectReference{
@@ -220,12 +342,17 @@ func (p *PackageHandler) CreatePackageHandler(w http.ResponseWriter, r *http.Req
 		}
 	}
 
-	err = p.Client.Create(context.Background(), pk)
+	tracing.InjectAnnotations(r.Context(), pk)
+	err = p.Client.Create(r.Context(), pk)
 	if err != nil {
 		fmt.Printf("error creating Package object: %v\n", *pk)
 		w.WriteHeader(500)
 		return
 	}
+	recordAuditEvent(r, p.Client, audit.AppPackageCreate, app, map[string]string{
+		"package_guid": pk.Name,
+		"type":         string(pk.Spec.Type),
+	})
 
 	// Format the in-memory package to the PresenterPackage type to match the CF API output JSON
 	formattedPackage := formatPresenterPackageResponse(pk)
@@ -255,7 +382,7 @@ func (p *PackageHandler) UploadPackageHandler(w http.ResponseWriter, r *http.Req
 	packageGuid := vars["guid"]
 	ctx := r.Context()
 
-	packages, err := getPackagesListFromQuery(&p.Client, map[string][]string{
+	packages, err := getPackagesListFromQuery(r.Context(), &p.Client, map[string][]string{
 		"guids": {packageGuid},
 	})
 	if len(packages) == 0 {
@@ -265,14 +392,14 @@ func (p *PackageHandler) UploadPackageHandler(w http.ResponseWriter, r *http.Req
 
 	packageBitsFile, _, err := r.FormFile("bits")
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 	defer packageBitsFile.Close()
 
 	tmpFile, err := ioutil.TempFile(os.TempDir(), fmt.Sprintf("package-%s", packageGuid))
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 	defer os.Remove(tmpFile.Name())
@@ -280,56 +407,52 @@ func (p *PackageHandler) UploadPackageHandler(w http.ResponseWriter, r *http.Req
 	fmt.Println("Created tmp file: " + tmpFile.Name())
 
 	if _, err = io.Copy(tmpFile, packageBitsFile); err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
 	// Close the packageBitsFile
 	if err := tmpFile.Close(); err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
 	image, err := random.Image(0, 0)
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
 	noopFilter := func(string) bool { return true }
 	layer, err := tarball.LayerFromReader(archive.ReadZipAsTar(tmpFile.Name(), "/", 0, 0, -1, true, noopFilter))
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
 	image, err = mutate.AppendLayers(image, layer)
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
 	registrySecretName := settings.GlobalSettings.RegistrySecret
-	packageRegistryBasePath := settings.GlobalSettings.PackageRegistryBase
 
-	ref, err := name.ParseReference(fmt.Sprintf("%s/%s", packageRegistryBasePath, packageGuid))
+	ref, err := name.ParseReference(generatePackageImageName(packageGuid))
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
-	keychain, err := p.KeychainFactory.KeychainForSecretRef(ctx, registry.SecretRef{
-		Namespace:        "default",
-		ImagePullSecrets: []corev1.LocalObjectReference{{Name: registrySecretName}},
-	})
+	keychain, err := p.packageRegistryKeychain(ctx)
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
 	err = remote.Write(ref, image, remote.WithAuthFromKeychain(keychain))
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
@@ -337,7 +460,7 @@ func (p *PackageHandler) UploadPackageHandler(w http.ResponseWriter, r *http.Req
 
 	imgDigest, err := image.Digest()
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
@@ -352,37 +475,343 @@ func (p *PackageHandler) UploadPackageHandler(w http.ResponseWriter, r *http.Req
 			ImagePullSecrets: []corev1.LocalObjectReference{{Name: registrySecretName}},
 		},
 	}
-	meta.SetStatusCondition(&updatedPkg.Status.Conditions, metav1.Condition{
-		Type:    "Succeeded",
-		Status:  metav1.ConditionTrue,
-		Reason:  "Uploaded",
-		Message: "",
-	})
-	meta.SetStatusCondition(&updatedPkg.Status.Conditions, metav1.Condition{
-		Type:    "Ready",
-		Status:  metav1.ConditionTrue,
-		Reason:  "Uploaded",
-		Message: "",
-	})
-	meta.SetStatusCondition(&updatedPkg.Status.Conditions, metav1.Condition{
-		Type:    "Uploaded",
-		Status:  metav1.ConditionTrue,
-		Reason:  "Uploaded",
-		Message: "",
-	})
+	setPackageUploadedConditions(&updatedPkg.Status.Conditions)
+	tracing.InjectAnnotations(ctx, updatedPkg)
 	err = p.Client.Patch(ctx, updatedPkg, client.MergeFrom(pkg))
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
 	err = p.Client.Status().Update(ctx, updatedPkg)
 	if err != nil {
-		ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+		returnServerError(w, err)
 		return
 	}
 
+	recordAuditEventOnAppGUID(r, p.Client, audit.AppPackageUpload, updatedPkg.Namespace, updatedPkg.Spec.AppRef.Name, map[string]string{"package_guid": updatedPkg.Name})
+
 	formattedMatchingPackage := formatPresenterPackageResponse(updatedPkg)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(formattedMatchingPackage)
 }
+
+// setPackageUploadedConditions marks a package as having its bits available in the registry
+func setPackageUploadedConditions(conditions *[]metav1.Condition) {
+	for _, conditionType := range []string{"Succeeded", "Ready", "Uploaded"} {
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type:    conditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Uploaded",
+			Message: "",
+		})
+	}
+}
+
+// generatePackageImageName returns the registry tag that holds the source image for a bits package
+func generatePackageImageName(packageGUID string) string {
+	return fmt.Sprintf("%s/%s", settings.GlobalSettings.PackageRegistryBase, packageGUID)
+}
+
+// packageRegistryKeychain returns a keychain with push and pull access to the package registry
+func (p *PackageHandler) packageRegistryKeychain(ctx context.Context) (authn.Keychain, error) {
+	return p.KeychainFactory.KeychainForSecretRef(ctx, registry.SecretRef{
+		Namespace:        "default",
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: settings.GlobalSettings.RegistrySecret}},
+	})
+}
+
+// CopyPackageHandler copies the bits or docker image of an existing package to a new package for the given app
+// Bits are copied registry-side from the source package image, nothing is re-uploaded
+// POST /v3/packages?source_guid=:guid
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#copy-a-package
+func (p *PackageHandler) CopyPackageHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	ctx := r.Context()
+
+	sourceGUID := r.URL.Query().Get("source_guid")
+
+	var copyRequest CFAPIPackageCopyResource
+	err := json.NewDecoder(r.Body).Decode(&copyRequest)
+	if err != nil {
+		fmt.Printf("error parsing request: %s\n", err)
+		ReturnFormattedError(w, 400, "CF-MessageParseError", "Request invalid due to parse error: invalid request body", 1001)
+		return
+	}
+
+	sourcePackages, err := getPackagesListFromQuery(r.Context(), &p.Client, map[string][]string{
+		"guids": {sourceGUID},
+	})
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+	if len(sourcePackages) == 0 {
+		ReturnFormattedError(w, 404, "CF-ResourceNotFound", "Package not found", 10010)
+		return
+	}
+	sourcePackage := sourcePackages[0]
+
+	matchedApps, err := getAppListFromQuery(r.Context(), &p.Client, map[string][]string{
+		"guids": {copyRequest.Relationships.App.Data.GUID},
+	})
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+	if len(matchedApps) == 0 {
+		ReturnFormattedError(w, 422, "CF-UnprocessableEntity", "Invalid app. Ensure that the app exists and you have access to it.", 10008)
+		return
+	}
+	app := matchedApps[0]
+
+	packageGUID := uuid.NewString()
+	pk := &appsv1alpha1.Package{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      packageGUID,
+			Namespace: app.Namespace,
+			Labels:    map[string]string{LabelAppGUID: app.Name},
+			OwnerReferences: []metav1.OwnerReference{
+				appsv1alpha1.AppOwnerReference(app),
+			},
+		},
+		Spec: appsv1alpha1.PackageSpec{
+			Type: sourcePackage.Spec.Type,
+			AppRef: appsv1alpha1.ApplicationReference{
+				Name: app.Name,
+			},
+			Source: *sourcePackage.Spec.Source.DeepCopy(),
+		},
+	}
+
+	if pk.Spec.Type == appsv1alpha1.DockerPackage {
+		// The credentials secret lives next to the source package, so it needs to follow the image into the new namespace
+		pullSecrets, err := p.copyPackageSecrets(ctx, sourcePackage, pk)
+		if err != nil {
+			returnServerError(w, err)
+			return
+		}
+		pk.Spec.Source.Registry.ImagePullSecrets = pullSecrets
+	} else if filters.DerivePackageState(sourcePackage) == "READY" {
+		imageName, err := p.copyPackageImage(ctx, sourcePackage.Spec.Source.Registry.Image, packageGUID)
+		if err != nil {
+			returnServerError(w, err)
+			return
+		}
+		pk.Spec.Source.Registry.Image = imageName
+	}
+
+	tracing.InjectAnnotations(ctx, pk)
+	err = p.Client.Create(ctx, pk)
+	if err != nil {
+		fmt.Printf("error creating Package object: %v\n", err)
+		returnServerError(w, err)
+		return
+	}
+
+	// Status is a subresource so it is dropped on create and must be set separately
+	if pk.Spec.Type == appsv1alpha1.BitsPackage && filters.DerivePackageState(sourcePackage) == "READY" {
+		pk.Status.Checksum = sourcePackage.Status.Checksum
+		setPackageUploadedConditions(&pk.Status.Conditions)
+		err = p.Client.Status().Update(ctx, pk)
+		if err != nil {
+			returnServerError(w, err)
+			return
+		}
+	}
+
+	recordAuditEvent(r, p.Client, audit.AppPackageCreate, app, map[string]string{
+		"package_guid":        pk.Name,
+		"type":                string(pk.Spec.Type),
+		"source_package_guid": sourcePackage.Name,
+	})
+
+	formattedPackage := formatPresenterPackageResponse(pk)
+	p.ReturnFormattedResponse(w, &formattedPackage)
+}
+
+// copyPackageImage copies the source image of a bits package to the registry tag of the new package and returns its name
+func (p *PackageHandler) copyPackageImage(ctx context.Context, sourceImage string, packageGUID string) (string, error) {
+	sourceRef, err := name.ParseReference(sourceImage)
+	if err != nil {
+		return "", err
+	}
+	destinationRef, err := name.ParseReference(generatePackageImageName(packageGUID))
+	if err != nil {
+		return "", err
+	}
+
+	keychain, err := p.packageRegistryKeychain(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	image, err := remote.Image(sourceRef, remote.WithAuthFromKeychain(keychain))
+	if err != nil {
+		return "", fmt.Errorf("error fetching source package image %s: %v", sourceImage, err)
+	}
+
+	// The layers already exist in the registry, so this only mounts blobs and writes a new manifest
+	err = remote.Write(destinationRef, image, remote.WithAuthFromKeychain(keychain))
+	if err != nil {
+		return "", fmt.Errorf("error writing package image %s: %v", destinationRef.Name(), err)
+	}
+
+	return destinationRef.Name(), nil
+}
+
+// copyPackageSecrets duplicates the image pull secrets of a docker package so they are owned by the new package
+func (p *PackageHandler) copyPackageSecrets(ctx context.Context, sourcePackage *appsv1alpha1.Package, pk *appsv1alpha1.Package) ([]corev1.LocalObjectReference, error) {
+	if len(sourcePackage.Spec.Source.Registry.ImagePullSecrets) == 0 {
+		return nil, nil
+	}
+
+	// Docker packages only ever reference the single secret created for them
+	sourceSecret, err := p.getSecretHelper(ctx, sourcePackage.Namespace, sourcePackage.Spec.Source.Registry.ImagePullSecrets[0].Name)
+	if err != nil {
+		return nil, err
+	}
+
+	secretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generatePackageSecretName(pk.Name),
+			Namespace: pk.Namespace,
+		},
+		Type: sourceSecret.Type,
+		Data: sourceSecret.Data,
+	}
+	err = p.Client.Create(ctx, secretObj)
+	if err != nil {
+		return nil, fmt.Errorf("error creating docker package Secret object: %v", err)
+	}
+
+	return []corev1.LocalObjectReference{{Name: secretObj.Name}}, nil
+}
+
+// DownloadPackageHandler streams the bits of a package back to the client as a zip file
+// The zip is reconstructed from the source layer that was appended to the package image on upload
+// GET /v3/packages/:guid/download
+// https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#download-package-bits
+func (p *PackageHandler) DownloadPackageHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	packageGUID := vars["guid"]
+	ctx := r.Context()
+
+	packages, err := getPackagesListFromQuery(r.Context(), &p.Client, map[string][]string{
+		"guids": {packageGUID},
+	})
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+	if len(packages) == 0 {
+		ReturnFormattedError(w, 404, "CF-ResourceNotFound", "Package not found", 10010)
+		return
+	}
+	pkg := packages[0]
+
+	if pkg.Spec.Type != appsv1alpha1.BitsPackage {
+		ReturnFormattedError(w, 422, "CF-UnprocessableEntity", "Package type must be bits.", 10008)
+		return
+	}
+	if filters.DerivePackageState(pkg) != "READY" {
+		ReturnFormattedError(w, 422, "CF-UnprocessableEntity", "Package has no bits to download.", 10008)
+		return
+	}
+
+	ref, err := name.ParseReference(pkg.Spec.Source.Registry.Image)
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+
+	keychain, err := p.packageRegistryKeychain(ctx)
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+
+	image, err := remote.Image(ref, remote.WithAuthFromKeychain(keychain))
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+
+	layers, err := image.Layers()
+	if err != nil {
+		returnServerError(w, err)
+		return
+	}
+	if len(layers) == 0 {
+		ReturnFormattedError(w, 500, "ServerError", "package image has no layers", 10001)
+		return
+	}
+
+	// The upload handler appends the source code as the last layer of the package image
+	sourceLayer := layers[len(layers)-1]
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.zip\"", pkg.Name))
+	w.WriteHeader(200)
+
+	// Headers are already sent at this point, so a failure can only be logged
+	if err := writeLayerAsZip(w, sourceLayer); err != nil {
+		fmt.Printf("error streaming package %s bits: %v\n", pkg.Name, err)
+	}
+}
+
+// writeLayerAsZip converts the tar contents of an image layer into a zip archive written to w
+func writeLayerAsZip(w io.Writer, layer v1.Layer) error {
+	layerReader, err := layer.Uncompressed()
+	if err != nil {
+		return err
+	}
+	defer layerReader.Close()
+
+	zipWriter := zip.NewWriter(w)
+	tarReader := tar.NewReader(layerReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// The upload handler roots every entry at "/", zip entries must be relative
+		entryName := strings.TrimPrefix(header.Name, "/")
+		if entryName == "" {
+			continue
+		}
+
+		zipHeader, err := zip.FileInfoHeader(header.FileInfo())
+		if err != nil {
+			return err
+		}
+		zipHeader.Name = entryName
+		if header.Typeflag == tar.TypeDir {
+			zipHeader.Name = strings.TrimSuffix(entryName, "/") + "/"
+		} else {
+			zipHeader.Method = zip.Deflate
+		}
+
+		entryWriter, err := zipWriter.CreateHeader(zipHeader)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			if _, err := io.Copy(entryWriter, tarReader); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if _, err := entryWriter.Write([]byte(header.Linkname)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return zipWriter.Close()
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPerPage = 50
+	maxPerPage     = 5000
+	defaultOrderBy = "created_at"
+)
+
+type CFAPIPagination struct {
+	TotalResults int        `json:"total_results"`
+	TotalPages   int        `json:"total_pages"`
+	First        CFAPILink  `json:"first"`
+	Last         CFAPILink  `json:"last"`
+	Next         *CFAPILink `json:"next"`
+	Previous     *CFAPILink `json:"previous"`
+}
+
+// pageParams are the CF paging and ordering query parameters of a list request
+// See: https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#pagination
+//
+// Pages are cut in memory after the List and the filters rather than with the Limit and Continue options of
+// client.List, since the CF filters, ordering and total_results all need the complete list anyway.
+type pageParams struct {
+	Page       int
+	PerPage    int
+	OrderBy    string
+	Descending bool
+}
+
+// pageParamsFromQuery reads page, per_page and order_by, orderByFields are the fields the endpoint can be ordered by
+// returns an error if any of them is invalid
+func pageParamsFromQuery(queryParams map[string][]string, orderByFields ...string) (pageParams, error) {
+	params := pageParams{Page: 1, PerPage: defaultPerPage, OrderBy: defaultOrderBy}
+
+	if values, ok := queryParams["page"]; ok {
+		page, err := strconv.Atoi(values[0])
+		if err != nil || page < 1 {
+			return params, fmt.Errorf("The query parameter is invalid: Page must be a positive integer")
+		}
+		params.Page = page
+	}
+
+	if values, ok := queryParams["per_page"]; ok {
+		perPage, err := strconv.Atoi(values[0])
+		if err != nil || perPage < 1 || perPage > maxPerPage {
+			return params, fmt.Errorf("The query parameter is invalid: Per page must be between 1 and %d", maxPerPage)
+		}
+		params.PerPage = perPage
+	}
+
+	if values, ok := queryParams["order_by"]; ok {
+		orderBy := strings.TrimPrefix(values[0], "-")
+		if !contains(orderByFields, orderBy) {
+			return params, fmt.Errorf("The query parameter is invalid: Order by can only be: '%s'", strings.Join(orderByFields, "', '"))
+		}
+		params.OrderBy = orderBy
+		params.Descending = strings.HasPrefix(values[0], "-")
+	}
+
+	return params, nil
+}
+
+// sortItems orders items, a slice, by the requested field
+// orderValue returns the value to order the i-th item by, and its guid to keep the order stable between requests
+func (p pageParams) sortItems(items interface{}, orderValue func(i int) (value string, guid string)) {
+	sort.Slice(items, func(i, j int) bool {
+		valueI, guidI := orderValue(i)
+		valueJ, guidJ := orderValue(j)
+		if valueI == valueJ {
+			return guidI < guidJ
+		}
+		return (valueI < valueJ) != p.Descending
+	})
+}
+
+// bounds returns the range of the requested page in a sorted list of total items
+func (p pageParams) bounds(total int) (start int, end int) {
+	start = (p.Page - 1) * p.PerPage
+	if start > total {
+		start = total
+	}
+	end = start + p.PerPage
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// pagination links to the other pages with the same query parameters as the request
+func (p pageParams) pagination(r *http.Request, total int) CFAPIPagination {
+	totalPages := (total + p.PerPage - 1) / p.PerPage
+	lastPage := totalPages
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	pagination := CFAPIPagination{
+		TotalResults: total,
+		TotalPages:   totalPages,
+		First:        p.pageLink(r, 1),
+		Last:         p.pageLink(r, lastPage),
+	}
+	if p.Page < lastPage {
+		next := p.pageLink(r, p.Page+1)
+		pagination.Next = &next
+	}
+	if p.Page > 1 {
+		previous := p.pageLink(r, p.Page-1)
+		if p.Page > lastPage {
+			previous = p.pageLink(r, lastPage)
+		}
+		pagination.Previous = &previous
+	}
+	return pagination
+}
+
+func (p pageParams) pageLink(r *http.Request, page int) CFAPILink {
+	query := r.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(p.PerPage))
+
+	return CFAPILink{Href: absoluteURL(r, r.URL.Path, query)}
+}
+
+// contains checks if the given string exists in the list
+func contains(values []string, input string) bool {
+	for _, value := range values {
+		if value == input {
+			return true
+		}
+	}
+	return false
+}
@@ -3,11 +3,13 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
 	"time"
 
 	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/cfshim/filters"
 )
 
 // Presenters- used for cfshim api responses
@@ -123,7 +125,8 @@ func formatBuildToPresenter(build *appsv1alpha1.Build) CFAPIBuildResource {
 
 	toReturn := CFAPIBuildResource{
 		GUID:      build.Name,
-		State:     deriveBuildState(build.Status.Conditions),
+		State:     deriveBuildState(build.Status),
+		Error:     formatBuildError(build.Status.Error),
 		CreatedAt: build.CreationTimestamp.UTC().Format(time.RFC3339),
 		UpdatedAt: "",
 		Lifecycle: CFAPILifecycle{
@@ -159,7 +162,17 @@ func formatBuildToPresenter(build *appsv1alpha1.Build) CFAPIBuildResource {
 	return toReturn
 }
 
-func deriveBuildState(conditions []metav1.Condition) string {
+// deriveBuildState presents the phase of a Build, CF has no PENDING builds so those are STAGING too
+// Builds the controller has not given a phase yet are derived from their conditions
+func deriveBuildState(status appsv1alpha1.BuildStatus) string {
+	switch status.Phase {
+	case appsv1alpha1.BuildStagedPhase, appsv1alpha1.BuildFailedPhase:
+		return string(status.Phase)
+	case appsv1alpha1.BuildPendingPhase, appsv1alpha1.BuildStagingPhase:
+		return "STAGING"
+	}
+
+	conditions := status.Conditions
 	if meta.IsStatusConditionTrue(conditions, appsv1alpha1.StagingConditionType) {
 		return "STAGING"
 	} else if meta.IsStatusConditionTrue(conditions, appsv1alpha1.SucceededConditionType) {
@@ -172,6 +185,15 @@ func deriveBuildState(conditions []metav1.Condition) string {
 	}
 }
 
+// formatBuildError presents the error of a FAILED Build the way Cloud Controller does, "<title> - <detail>"
+func formatBuildError(buildError *appsv1alpha1.BuildError) *string {
+	if buildError == nil {
+		return nil
+	}
+	formatted := strings.TrimPrefix(buildError.Title, "CF-") + " - " + buildError.Detail
+	return &formatted
+}
+
 //---------------------------------------------------------------------------------------
 // PACKAGE PRESENTER
 //---------------------------------------------------------------------------------------
@@ -239,7 +261,7 @@ func formatPresenterPackageResponse(pk *appsv1alpha1.Package) CFAPIPresenterPack
 			//	it is used to format the JSON for bits and docker types differently
 			Type: string(pk.Spec.Type),
 		},
-		State:     derivePackageState(pk.Status.Conditions),
+		State:     filters.DerivePackageState(pk),
 		CreatedAt: pk.CreationTimestamp.UTC().Format(time.RFC3339),
 		// TODO: Not sure how to get updated time, it is not present on CR for free
 		UpdatedAt: "",
@@ -265,7 +287,6 @@ func formatPresenterPackageResponse(pk *appsv1alpha1.Package) CFAPIPresenterPack
 		toReturn.Data.Error = nil
 	} else if toReturn.Type == "docker" {
 		toReturn.Data.Image = pk.Spec.Source.Registry.Image
-		toReturn.State = "READY"
 	}
 
 	updatedAt, err := getTimeLastUpdatedTimestamp(&pk.ObjectMeta)
@@ -276,16 +297,6 @@ func formatPresenterPackageResponse(pk *appsv1alpha1.Package) CFAPIPresenterPack
 	return toReturn
 }
 
-func derivePackageState(Conditions []metav1.Condition) string {
-	if meta.IsStatusConditionTrue(Conditions, "Succeeded") &&
-		meta.IsStatusConditionTrue(Conditions, "Uploaded") &&
-		meta.IsStatusConditionTrue(Conditions, "Ready") {
-		return "READY"
-	} else {
-		return "AWAITING_UPLOAD"
-	}
-}
-
 //---------------------------------------------------------------------------------------
 // DROPLET PRESENTER
 //---------------------------------------------------------------------------------------
@@ -302,3 +313,193 @@ func formatSetDropletResponse(app *appsv1alpha1.App) CFAPIPresenterAppRelationsh
 		Links: map[string]CFAPILink{},
 	}
 }
+
+func formatDropletToPresenter(droplet *appsv1alpha1.Droplet) CFAPIDropletResource {
+	processTypes := map[string]string{}
+	for _, processType := range droplet.Spec.ProcessTypes {
+		for name, command := range processType {
+			processTypes[name] = command
+		}
+	}
+
+	var image *string
+	if droplet.Spec.Registry.Image != "" {
+		image = &droplet.Spec.Registry.Image
+	}
+
+	toReturn := CFAPIDropletResource{
+		GUID:  droplet.Name,
+		State: deriveDropletState(droplet),
+		Lifecycle: CFAPIDropletLifecycle{
+			Type: string(droplet.Spec.Type),
+			// Force the data to look like {} instead of null
+			Data: make(map[string]interface{}),
+		},
+		ExecutionMetadata: droplet.Status.LifecycleData.ExecutionMetadata,
+		ProcessTypes:      processTypes,
+		Buildpacks:        []string{},
+		Image:             image,
+		CreatedAt:         droplet.CreationTimestamp.UTC().Format(time.RFC3339),
+		UpdatedAt:         "",
+		Relationships: CFAPIBuildRelationships{
+			App: CFAPIBuildRelationshipsApps{
+				Data: CFAPIBuildRelationshipsAppsData{
+					GUID: droplet.Spec.AppRef.Name,
+				},
+			},
+		},
+		Links: map[string]CFAPILink{},
+		Metadata: CFAPIMetadata{
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+	}
+	updatedAt, err := getTimeLastUpdatedTimestamp(&droplet.ObjectMeta)
+	if err != nil {
+		fmt.Printf("Error finding last updated time for droplet %s: %v\n", droplet.Name, err)
+	}
+	toReturn.UpdatedAt = updatedAt
+	return toReturn
+}
+
+// deriveDropletState considers a Droplet staged once it has an image, Builds only create their Droplet once kpack is done
+func deriveDropletState(droplet *appsv1alpha1.Droplet) string {
+	if droplet.Spec.Registry.Image == "" {
+		return "AWAITING_UPLOAD"
+	}
+	return "STAGED"
+}
+
+//---------------------------------------------------------------------------------------
+// SPACE AND ORGANIZATION PRESENTERS
+//---------------------------------------------------------------------------------------
+// Spaces are Namespaces, named by their apps.cloudfoundry.org/spaceName annotation if they have one
+// There are no organization resources, a Namespace belongs to the one in its apps.cloudfoundry.org/orgGuid label
+func formatSpaceToPresenter(namespace *corev1.Namespace) CFAPISpaceResource {
+	name := namespace.Name
+	if spaceName, ok := namespace.Annotations[AnnotationSpaceName]; ok {
+		name = spaceName
+	}
+
+	toReturn := CFAPISpaceResource{
+		GUID:      namespace.Name,
+		Name:      name,
+		CreatedAt: namespace.CreationTimestamp.UTC().Format(time.RFC3339),
+		UpdatedAt: "",
+		Links:     map[string]CFAPILink{},
+		Metadata: CFAPIMetadata{
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+	}
+	if orgGUID, ok := namespace.Labels[LabelOrgGUID]; ok {
+		toReturn.Relationships.Organization.Data = &CFAPISpaceRelationshipsOrganizationData{
+			GUID: orgGUID,
+		}
+	}
+	updatedAt, err := getTimeLastUpdatedTimestamp(&namespace.ObjectMeta)
+	if err != nil {
+		fmt.Printf("Error finding last updated time for namespace %s: %v\n", namespace.Name, err)
+	}
+	toReturn.UpdatedAt = updatedAt
+	return toReturn
+}
+
+// formatOrganizationToPresenter presents the organization of a Namespace, it returns false if it has none
+func formatOrganizationToPresenter(namespace *corev1.Namespace) (CFAPIOrganizationResource, bool) {
+	orgGUID, ok := namespace.Labels[LabelOrgGUID]
+	if !ok {
+		return CFAPIOrganizationResource{}, false
+	}
+
+	name := orgGUID
+	if orgName, ok := namespace.Annotations[AnnotationOrgName]; ok {
+		name = orgName
+	}
+	return CFAPIOrganizationResource{
+		GUID:  orgGUID,
+		Name:  name,
+		Links: map[string]CFAPILink{},
+		Metadata: CFAPIMetadata{
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+	}, true
+}
+
+//---------------------------------------------------------------------------------------
+// JOB PRESENTER
+//---------------------------------------------------------------------------------------
+func formatJobToPresenter(job *appsv1alpha1.Job) CFAPIJobResource {
+	state := job.Status.State
+	if state == "" {
+		// The JobReconciler has not seen the Job yet
+		state = appsv1alpha1.JobProcessingState
+	}
+
+	toReturn := CFAPIJobResource{
+		GUID:      job.Name,
+		CreatedAt: job.CreationTimestamp.UTC().Format(time.RFC3339),
+		UpdatedAt: "",
+		Operation: job.Spec.Operation,
+		State:     string(state),
+		Errors:    []CFAPIError{},
+		Warnings:  []CFAPIJobWarning{},
+		Links:     map[string]CFAPILink{},
+	}
+	for _, jobError := range job.Status.Errors {
+		toReturn.Errors = append(toReturn.Errors, CFAPIError{
+			Detail: jobError.Detail,
+			Title:  jobError.Title,
+			Code:   jobError.Code,
+		})
+	}
+	for _, warning := range job.Status.Warnings {
+		toReturn.Warnings = append(toReturn.Warnings, CFAPIJobWarning{Detail: warning.Detail})
+	}
+	updatedAt, err := getTimeLastUpdatedTimestamp(&job.ObjectMeta)
+	if err != nil {
+		fmt.Printf("Error finding last updated time for job %s: %v\n", job.Name, err)
+	}
+	toReturn.UpdatedAt = updatedAt
+	return toReturn
+}
+
+//---------------------------------------------------------------------------------------
+// AUDIT EVENT PRESENTER
+//---------------------------------------------------------------------------------------
+
+// formatAuditEventToPresenter presents an AuditEvent, the Namespace it is in is its space
+func formatAuditEventToPresenter(event *appsv1alpha1.AuditEvent, orgGUID string) CFAPIAuditEventResource {
+	toReturn := CFAPIAuditEventResource{
+		GUID:      event.Name,
+		CreatedAt: event.CreationTimestamp.UTC().Format(time.RFC3339),
+		UpdatedAt: "",
+		Type:      event.Spec.Type,
+		Actor: CFAPIAuditEventActor{
+			GUID: event.Spec.Actor.GUID,
+			Type: event.Spec.Actor.Type,
+			Name: event.Spec.Actor.Name,
+		},
+		Target: CFAPIAuditEventTarget{
+			GUID: event.Spec.Target.GUID,
+			Type: event.Spec.Target.Type,
+			Name: event.Spec.Target.Name,
+		},
+		Data:  map[string]string{},
+		Space: &CFAPIAuditEventGUID{GUID: event.Namespace},
+		Links: map[string]CFAPILink{},
+	}
+	for key, value := range event.Spec.Data {
+		toReturn.Data[key] = value
+	}
+	if orgGUID != "" {
+		toReturn.Organization = &CFAPIAuditEventGUID{GUID: orgGUID}
+	}
+	updatedAt, err := getTimeLastUpdatedTimestamp(&event.ObjectMeta)
+	if err != nil {
+		fmt.Printf("Error finding last updated time for audit event %s: %v\n", event.Name, err)
+	}
+	toReturn.UpdatedAt = updatedAt
+	return toReturn
+}
@@ -6,13 +6,19 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/cfshim/auth"
 	"cloudfoundry.org/cf-crd-explorations/cfshim/filters"
+	"cloudfoundry.org/cf-crd-explorations/indexes"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
 func ReturnFormattedError(w http.ResponseWriter, status int, title string, detail string, code int) {
@@ -29,6 +35,108 @@ func ReturnFormattedError(w http.ResponseWriter, status int, title string, detai
 	})
 }
 
+// returnServerError answers 500 with err, unless Kubernetes RBAC forbade the caller what the handler tried on their
+// behalf, which is answered like Cloud Controller answers a user without the role for it
+func returnServerError(w http.ResponseWriter, err error) {
+	if apierrors.IsForbidden(err) {
+		ReturnFormattedError(w, 403, "CF-NotAuthorized", "You are not authorized to perform the requested action", 10003)
+		return
+	}
+	ReturnFormattedError(w, 500, "ServerError", err.Error(), 10001)
+}
+
+// deleteAsync starts deleting the object and answers 202 Accepted with the Job that tracks the deletion
+// The object is gone once its finalizers have cleaned up after it, the garbage collector then deletes what it owns
+// returns false if it answered with an error instead
+func deleteAsync(w http.ResponseWriter, r *http.Request, c client.Client, obj client.Object, operation string) bool {
+	err := c.Delete(r.Context(), obj, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !apierrors.IsNotFound(err) {
+		fmt.Printf("error deleting %s %s: %v\n", operation, obj.GetName(), err)
+		returnServerError(w, err)
+		return false
+	}
+
+	// The Job is only created once the caller was allowed to delete the object, they can not write Jobs themselves
+	// A Job of an object that is gone already completes right away
+	job, err := createJob(auth.AsShim(r.Context()), c, obj, operation)
+	if err != nil {
+		fmt.Printf("error creating %s job for %s: %v\n", operation, obj.GetName(), err)
+		returnServerError(w, err)
+		return false
+	}
+
+	w.Header().Set("Location", absoluteURL(r, JobsEndpoint+"/"+job.Name, nil))
+	w.WriteHeader(202)
+	return true
+}
+
+// createJob creates the Job of an operation on the object, in its namespace
+// The Job is named after the operation and the object, so repeating an operation that is in progress leads to the same Job
+// A finished Job of the operation is kept for a while, see JobReconciler, repeating the operation replaces it
+func createJob(ctx context.Context, c client.Client, obj client.Object, operation string) (*appsv1alpha1.Job, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return nil, err
+	}
+
+	job := &appsv1alpha1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      operation + "-" + obj.GetName(),
+			Namespace: obj.GetNamespace(),
+		},
+		Spec: appsv1alpha1.JobSpec{
+			Operation: operation,
+			ResourceRef: appsv1alpha1.JobResourceReference{
+				APIVersion: gvk.GroupVersion().String(),
+				Kind:       gvk.Kind,
+				Name:       obj.GetName(),
+			},
+		},
+	}
+	err = c.Create(ctx, job)
+	if !apierrors.IsAlreadyExists(err) {
+		return job, err
+	}
+
+	existing := &appsv1alpha1.Job{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(job), existing); apierrors.IsNotFound(err) {
+		return job, c.Create(ctx, job)
+	} else if err != nil {
+		return nil, err
+	}
+	if existing.Spec.Operation != job.Spec.Operation || existing.Spec.ResourceRef != job.Spec.ResourceRef {
+		return nil, fmt.Errorf("job %s is already tracking %s of %s %s", existing.Name, existing.Spec.Operation, existing.Spec.ResourceRef.Kind, existing.Spec.ResourceRef.Name)
+	}
+	if existing.Status.State != appsv1alpha1.JobCompleteState && existing.Status.State != appsv1alpha1.JobFailedState {
+		return existing, nil
+	}
+
+	// Only delete the finished Job we read, a concurrent request may have replaced it already
+	err = c.Delete(ctx, existing, client.Preconditions{UID: &existing.UID})
+	if client.IgnoreNotFound(err) != nil && !apierrors.IsConflict(err) {
+		return nil, err
+	}
+	err = c.Create(ctx, job)
+	if apierrors.IsAlreadyExists(err) {
+		return job, nil
+	}
+	return job, err
+}
+
+// absoluteURL returns the URL of path on the server that received the request
+func absoluteURL(r *http.Request, path string, query url.Values) string {
+	link := url.URL{
+		Scheme:   "http",
+		Host:     r.Host,
+		Path:     path,
+		RawQuery: query.Encode(),
+	}
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		link.Scheme = "https"
+	}
+	return link.String()
+}
+
 // getTimeLastUpdatedTimestamp takes the ObjectMeta from a CR and extracts the last updated time from its list of ManagedFields
 // Returns an error if the list is empty or the time could not be extracted
 func getTimeLastUpdatedTimestamp(metadata *metav1.ObjectMeta) (string, error) {
@@ -36,18 +144,7 @@ func getTimeLastUpdatedTimestamp(metadata *metav1.ObjectMeta) (string, error) {
 		return "", errors.New("error, metadata.ManagedFields was empty")
 	}
 
-	latestTime := metadata.ManagedFields[0].Time
-	for i := 1; i < len(metadata.ManagedFields); i++ {
-		var currentTime = metadata.ManagedFields[i].Time
-		if latestTime == nil {
-			latestTime = currentTime
-		} else if currentTime != nil {
-			if currentTime.After(latestTime.Time) {
-				latestTime = currentTime
-			}
-		}
-	}
-
+	latestTime := filters.UpdatedAt(metadata)
 	if latestTime == nil {
 		return "", errors.New("error, could not find a time in metadata.ManagedFields")
 	}
@@ -55,17 +152,23 @@ func getTimeLastUpdatedTimestamp(metadata *metav1.ObjectMeta) (string, error) {
 	return latestTime.UTC().Format(time.RFC3339), nil
 }
 
-// getAppListFromQuery takes URL query parameters and queries the K8s Client for all Apps
+// getAppListFromQuery takes URL query parameters and queries the K8s Client for the Apps matching an index on them
 // builds a filter based on params and walks through, placing every match into the returned list of Apps
-// returns an error if something went wrong with the K8s query
-func getAppListFromQuery(c *client.Client, queryParameters map[string][]string) ([]*appsv1alpha1.App, error) {
-	var filter Filter = &filters.AppFilter{
-		QueryParameters: queryParameters,
+// returns an error if the params are not valid filters for Apps or something went wrong with the K8s query
+func getAppListFromQuery(ctx context.Context, c *client.Client, queryParameters map[string][]string, opts ...client.ListOption) ([]*appsv1alpha1.App, error) {
+	filter, err := filters.AppFields.Parse(queryParameters)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get all the CF Apps from K8s API store in AllApps which contains Items: []App
+	// Narrow the List down with an index where possible, the filter applies the remaining query parameters
+	opts = append(indexListOptions(queryParameters,
+		indexedParameter{"guids", indexes.Name},
+		indexedParameter{"names", indexes.AppName},
+	), opts...)
+
 	AllApps := &appsv1alpha1.AppList{}
-	err := (*c).List(context.Background(), AllApps)
+	err = (*c).List(ctx, AllApps, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching app: %v", err)
 	}
@@ -73,20 +176,26 @@ func getAppListFromQuery(c *client.Client, queryParameters map[string][]string)
 	// Apply filter to AllApps and store result in matchedApps
 	var matchedApps []*appsv1alpha1.App
 	for i, _ := range AllApps.Items {
-		if filter.Filter(&AllApps.Items[i]) {
+		if filter.Matches(&AllApps.Items[i]) {
 			matchedApps = append(matchedApps, &AllApps.Items[i])
 		}
 	}
 	return matchedApps, nil
 }
 
-func getPackagesListFromQuery(c *client.Client, queryParameters map[string][]string) ([]*appsv1alpha1.Package, error) {
-	var filter Filter = &filters.PackageFilter{
-		QueryParameters: queryParameters,
+func getPackagesListFromQuery(ctx context.Context, c *client.Client, queryParameters map[string][]string, opts ...client.ListOption) ([]*appsv1alpha1.Package, error) {
+	filter, err := filters.PackageFields.Parse(queryParameters)
+	if err != nil {
+		return nil, err
 	}
 
+	opts = append(indexListOptions(queryParameters,
+		indexedParameter{"guids", indexes.Name},
+		indexedParameter{"app_guids", indexes.AppRef},
+	), opts...)
+
 	AllPackages := &appsv1alpha1.PackageList{}
-	err := (*c).List(context.Background(), AllPackages)
+	err = (*c).List(ctx, AllPackages, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching package: %v", err)
 	}
@@ -94,61 +203,25 @@ func getPackagesListFromQuery(c *client.Client, queryParameters map[string][]str
 	// Apply filter to AllPackages and store result in matchedPackages
 	var matchedPackages []*appsv1alpha1.Package
 	for i, _ := range AllPackages.Items {
-		if filter.Filter(&AllPackages.Items[i]) {
+		if filter.Matches(&AllPackages.Items[i]) {
 			matchedPackages = append(matchedPackages, &AllPackages.Items[i])
 		}
 	}
 	return matchedPackages, nil
 }
 
-func getMatchingResources(c *client.Client, queryParameters map[string][]string, i interface{}) ([]interface{}, error) {
-	var filter Filter
-	var matchedPackages []interface{}
-
-	switch i.(type) {
-
-	case appsv1alpha1.Package:
-		filter = &filters.PackageFilter{
-			QueryParameters: queryParameters,
-		}
-
-		objectlist := &appsv1alpha1.PackageList{}
-		err := (*c).List(context.Background(), objectlist)
-		if err != nil {
-			return nil, fmt.Errorf("error fetching package: %v", err)
-		}
-		for i, _ := range objectlist.Items {
-			if filter.Filter(&objectlist.Items[i]) {
-				matchedPackages = append(matchedPackages, &objectlist.Items[i])
-			}
-		}
-	case appsv1alpha1.App:
-		filter = &filters.AppFilter{
-			QueryParameters: queryParameters,
-		}
-
-		objectlist := &appsv1alpha1.AppList{}
-		err := (*c).List(context.Background(), objectlist)
-		if err != nil {
-			return nil, fmt.Errorf("error fetching package: %v", err)
-		}
-		for i, _ := range objectlist.Items {
-			if filter.Filter(&objectlist.Items[i]) {
-				matchedPackages = append(matchedPackages, &objectlist.Items[i])
-			}
-		}
-
+func getDropletListFromQuery(ctx context.Context, c *client.Client, queryParameters map[string][]string, opts ...client.ListOption) ([]*appsv1alpha1.Droplet, error) {
+	filter, err := filters.DropletFields.Parse(queryParameters)
+	if err != nil {
+		return nil, err
 	}
-	return matchedPackages, nil
-}
 
-func getDropletListFromQuery(c *client.Client, queryParameters map[string][]string) ([]*appsv1alpha1.Droplet, error) {
-	var filter Filter = &filters.DropletFilter{
-		QueryParameters: queryParameters,
-	}
+	opts = append(indexListOptions(queryParameters,
+		indexedParameter{"guids", indexes.Name},
+	), opts...)
 
 	AllDroplets := &appsv1alpha1.DropletList{}
-	err := (*c).List(context.Background(), AllDroplets)
+	err = (*c).List(ctx, AllDroplets, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching app: %v", err)
 	}
@@ -156,24 +229,28 @@ func getDropletListFromQuery(c *client.Client, queryParameters map[string][]stri
 	// Apply filter to AllApps and store result in matchedDroplets
 	var matchedDroplets []*appsv1alpha1.Droplet
 	for i, _ := range AllDroplets.Items {
-		if filter.Filter(&AllDroplets.Items[i]) {
+		if filter.Matches(&AllDroplets.Items[i]) {
 			matchedDroplets = append(matchedDroplets, &AllDroplets.Items[i])
 		}
 	}
 	return matchedDroplets, nil
 }
 
-// getBuildListFromQuery takes URL query parameters and queries the K8s Client for all Builds
+// getBuildListFromQuery takes URL query parameters and queries the K8s Client for the Builds matching an index on them
 // builds a filter based on params and walks through, placing every match into the returned list of Builds
-// returns an error if something went wrong with the K8s query
-func getBuildListFromQuery(c *client.Client, queryParameters map[string][]string) ([]*appsv1alpha1.Build, error) {
-	var filter Filter = &filters.BuildFilter{
-		QueryParameters: queryParameters,
+// returns an error if the params are not valid filters for Builds or something went wrong with the K8s query
+func getBuildListFromQuery(ctx context.Context, c *client.Client, queryParameters map[string][]string, opts ...client.ListOption) ([]*appsv1alpha1.Build, error) {
+	filter, err := filters.BuildFields.Parse(queryParameters)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get all the CF Apps from K8s API store in AllApps which contains Items: []App
+	opts = append(indexListOptions(queryParameters,
+		indexedParameter{"guids", indexes.Name},
+	), opts...)
+
 	AllBuilds := &appsv1alpha1.BuildList{}
-	err := (*c).List(context.Background(), AllBuilds)
+	err = (*c).List(ctx, AllBuilds, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching app: %v", err)
 	}
@@ -181,13 +258,45 @@ func getBuildListFromQuery(c *client.Client, queryParameters map[string][]string
 	// Apply filter to AllApps and store result in matchedBuilds
 	var matchedBuilds []*appsv1alpha1.Build
 	for i, _ := range AllBuilds.Items {
-		if filter.Filter(&AllBuilds.Items[i]) {
+		if filter.Matches(&AllBuilds.Items[i]) {
 			matchedBuilds = append(matchedBuilds, &AllBuilds.Items[i])
 		}
 	}
 	return matchedBuilds, nil
 }
 
+// getAuditEventListFromQuery takes URL query parameters and queries the K8s Client for the AuditEvents matching an index on them
+// returns an error if the params are not valid filters for AuditEvents or something went wrong with the K8s query
+func getAuditEventListFromQuery(ctx context.Context, c *client.Client, queryParameters map[string][]string, opts ...client.ListOption) ([]*appsv1alpha1.AuditEvent, error) {
+	filter, err := filters.AuditEventFields.Parse(queryParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(indexListOptions(queryParameters,
+		indexedParameter{"guids", indexes.Name},
+		indexedParameter{"target_guids", indexes.AuditEventTarget},
+	), opts...)
+	// The events of a space are in its namespace
+	if spaceGUIDs := queryParameters["space_guids"]; len(spaceGUIDs) == 1 {
+		opts = append(opts, client.InNamespace(spaceGUIDs[0]))
+	}
+
+	AllAuditEvents := &appsv1alpha1.AuditEventList{}
+	err = (*c).List(ctx, AllAuditEvents, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching audit events: %v", err)
+	}
+
+	var matchedAuditEvents []*appsv1alpha1.AuditEvent
+	for i := range AllAuditEvents.Items {
+		if filter.Matches(&AllAuditEvents.Items[i]) {
+			matchedAuditEvents = append(matchedAuditEvents, &AllAuditEvents.Items[i])
+		}
+	}
+	return matchedAuditEvents, nil
+}
+
 // formatQueryParams takes a map of string query parameters and splits any entries with commas in them in-place
 func formatQueryParams(queryParams map[string][]string) {
 	for key, value := range queryParams {
@@ -199,3 +308,38 @@ func formatQueryParams(queryParams map[string][]string) {
 		queryParams[key] = newParamsList
 	}
 }
+
+// indexedParameter is a query parameter with a field index on the same values, see package indexes
+type indexedParameter struct {
+	parameter string
+	index     string
+}
+
+// indexListOptions narrows a List to the first of the indexed query parameters that has a single value
+// The cache can only look up one index at a time, so the other parameters are still left to the filters
+func indexListOptions(queryParameters map[string][]string, indexed ...indexedParameter) []client.ListOption {
+	for _, p := range indexed {
+		if values := queryParameters[p.parameter]; len(values) == 1 {
+			return []client.ListOption{client.MatchingFields{p.index: values[0]}}
+		}
+	}
+	return nil
+}
+
+// listOptionsFromQuery turns the query parameters the Kubernetes API can filter on into options for client.List
+// label_selector uses the same syntax as Kubernetes label selectors, e.g. env=prod,tier!=frontend,team in (a,b)
+// returns an error if the label_selector is invalid
+func listOptionsFromQuery(queryParams map[string][]string) ([]client.ListOption, error) {
+	var opts []client.ListOption
+
+	if values, ok := queryParams["label_selector"]; ok {
+		// formatQueryParams splits on commas, which are also the requirement separator in a selector
+		selector, err := labels.Parse(strings.Join(values, ","))
+		if err != nil {
+			return nil, fmt.Errorf("The query parameter is invalid: label_selector is invalid: %v", err)
+		}
+		opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	}
+
+	return opts, nil
+}
@@ -4,12 +4,12 @@ const (
 	LabelAppGUID     = "apps.cloudfoundry.org/appGuid"
 	LabelPackageGUID = "apps.cloudfoundry.org/packageGuid"
 	LabelBuildGUID   = "apps.cloudfoundry.org/buildGuid"
-)
 
-type Filter interface {
-	// Filter takes an object, casts it uses preset filters and returns yes/no
-	Filter(interface{}) bool
-}
+	// Spaces are Namespaces, these optionally name the space and place it in an organization
+	AnnotationSpaceName = "apps.cloudfoundry.org/spaceName"
+	LabelOrgGUID        = "apps.cloudfoundry.org/orgGuid"
+	AnnotationOrgName   = "apps.cloudfoundry.org/orgName"
+)
 
 type CFAPILifecycle struct {
 	Type string                  `json:"type"`
@@ -45,6 +45,10 @@ type CFAPIPackageResource struct {
 	Data          *CFAPIPackageData            `json:"data,omitempty"`
 }
 
+type CFAPIPackageCopyResource struct {
+	Relationships CFAPIPackageAppRelationships `json:"relationships"`
+}
+
 type CFAPIPackageAppRelationships struct {
 	App CFAPIPackageAppRelationshipsApp `json:"app"`
 }
@@ -0,0 +1,32 @@
+package handlers
+
+type CFAPISpaceResource struct {
+	GUID          string                  `json:"guid"`
+	Name          string                  `json:"name"`
+	CreatedAt     string                  `json:"created_at"`
+	UpdatedAt     string                  `json:"updated_at"`
+	Relationships CFAPISpaceRelationships `json:"relationships"`
+	Links         map[string]CFAPILink    `json:"links"`
+	Metadata      CFAPIMetadata           `json:"metadata"`
+}
+
+type CFAPISpaceRelationships struct {
+	Organization CFAPISpaceRelationshipsOrganization `json:"organization"`
+}
+
+type CFAPISpaceRelationshipsOrganization struct {
+	// Data is null for Namespaces without the apps.cloudfoundry.org/orgGuid label
+	Data *CFAPISpaceRelationshipsOrganizationData `json:"data"`
+}
+
+type CFAPISpaceRelationshipsOrganizationData struct {
+	GUID string `json:"guid"`
+}
+
+type CFAPIOrganizationResource struct {
+	GUID      string               `json:"guid"`
+	Name      string               `json:"name"`
+	Suspended bool                 `json:"suspended"`
+	Links     map[string]CFAPILink `json:"links"`
+	Metadata  CFAPIMetadata        `json:"metadata"`
+}
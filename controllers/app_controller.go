@@ -21,8 +21,18 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"cloudfoundry.org/cf-crd-explorations/cfshim/handlers"
+	"cloudfoundry.org/cf-crd-explorations/indexes"
+	"cloudfoundry.org/cf-crd-explorations/settings"
+	"cloudfoundry.org/cf-crd-explorations/webhooks/validate"
+	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
+	"github.com/pivotal/kpack/pkg/registry"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -33,23 +43,32 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	cfappsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/tracing"
 )
 
+// AppCleanupFinalizer holds a deleted App until the objects and registry images it leaves behind are deleted
+const AppCleanupFinalizer = "apps.cloudfoundry.org/app-cleanup"
+
 // AppReconciler reconciles a App object
 type AppReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme          *runtime.Scheme
+	Recorder        record.EventRecorder
+	KeychainFactory registry.KeychainFactory
 }
 
 //+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=apps,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=apps/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=apps/finalizers,verbs=update
 //+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;update;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -67,13 +86,36 @@ func (r *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	app := new(cfappsv1alpha1.App)
 	if err := r.Get(ctx, req.NamespacedName, app); err != nil {
 		logger.Info(fmt.Sprintf("Error fetching app: %s", err))
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	ctx, span := tracing.StartReconcile(ctx, "AppReconciler", app, app)
+	defer span.End()
+
+	if !app.DeletionTimestamp.IsZero() {
+		err := r.cleanupApp(ctx, app)
+		if err != nil {
+			r.Recorder.Event(app, corev1.EventTypeWarning, CleanupFailedReason, err.Error())
+		}
+		return ctrl.Result{}, err
+	}
+	if !controllerutil.ContainsFinalizer(app, AppCleanupFinalizer) {
+		controllerutil.AddFinalizer(app, AppCleanupFinalizer)
+		if err := r.Update(ctx, app); err != nil {
+			logger.Info(fmt.Sprintf("Error adding finalizer to app: %s", err))
+			return ctrl.Result{}, err
+		}
+	}
+
+	requeueAfter, err := r.reconcileNameLeases(ctx, app)
+	if err != nil {
+		logger.Info(fmt.Sprintf("Error reconciling app name leases: %s", err))
 		return ctrl.Result{}, err
 	}
 
 	// If there isn't a current droplet set, don't return an error as this will cause a retry loop
 	// once the app spec changes with this information, it'll reconcile then
 	if app.Spec.CurrentDropletRef.Name == "" {
-		return ctrl.Result{}, nil
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
 	}
 
 	// Fetch the Droplet to get the imageRef
@@ -97,7 +139,7 @@ func (r *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 
 			var exposedPorts []int32
 			if len(droplet.Spec.Ports) == 0 {
-				exposedPorts = []int32{8080}
+				exposedPorts = []int32{settings.GlobalDefaults.Port}
 			} else {
 				exposedPorts = droplet.Spec.Ports
 			}
@@ -117,12 +159,7 @@ func (r *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 						"apps.cloudfoundry.org/processType": processType,
 					},
 					OwnerReferences: []metav1.OwnerReference{
-						{
-							APIVersion: cfappsv1alpha1.SchemeBuilder.GroupVersion.String(),
-							Kind:       app.Kind,
-							Name:       app.Name,
-							UID:        app.UID,
-						},
+						cfappsv1alpha1.AppOwnerReference(app),
 					},
 				},
 				Spec: cfappsv1alpha1.ProcessSpec{
@@ -133,15 +170,15 @@ func (r *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 					},
 					ProcessType: processType,
 					Command:     command,
-					State:       "STOPPED", // This is the default
+					State:       cfappsv1alpha1.DesiredState(settings.GlobalDefaults.DesiredState),
 					HealthCheck: cfappsv1alpha1.HealthCheck{
 						// This is set to process since this information needs to be provided later on
 						// API for updating health check: https://v3-apidocs.cloudfoundry.org/version/3.101.0/index.html#update-a-process
-						Type: "process",
+						Type: cfappsv1alpha1.HealthCheckType(settings.GlobalDefaults.HealthCheckType),
 					},
 					Instances:   instances,
-					MemoryMB:    500, // TODO: find CF default values
-					DiskQuotaMB: 512, // TODO: find CF default values
+					MemoryMB:    settings.GlobalDefaults.MemoryMB,
+					DiskQuotaMB: settings.GlobalDefaults.DiskQuotaMB,
 					Ports:       exposedPorts,
 				},
 			}
@@ -160,6 +197,11 @@ func (r *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 			}
 
 			logger.Info(fmt.Sprintf("Successfully Created/Updated App: %s", result))
+			if result == controllerutil.OperationResultCreated {
+				r.Recorder.Eventf(app, corev1.EventTypeNormal, ProcessCreatedReason, "Created %s process %s", processType, processGuid)
+			} else if result == controllerutil.OperationResultUpdated {
+				r.Recorder.Eventf(app, corev1.EventTypeNormal, ProcessUpdatedReason, "Updated %s process %s", processType, processGuid)
+			}
 		}
 	}
 
@@ -172,7 +214,103 @@ func (r *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	}
 
 	logger.Info("Done reconciling")
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// cleanupApp deletes what owner references do not cover for a deleted App: its env Secret and the tag kpack pushed its
+// builds to, then removes the finalizer. Processes, Packages, Builds and Droplets are owned by the App, but objects created
+// before they carried owner references are still deleted here along with their kpack Images
+// Packages and Droplets delete their own secrets and images, see PackageCleanupFinalizer and DropletCleanupFinalizer
+func (r *AppReconciler) cleanupApp(ctx context.Context, app *cfappsv1alpha1.App) error {
+	if !controllerutil.ContainsFinalizer(app, AppCleanupFinalizer) {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+
+	if app.Spec.EnvSecretName != "" {
+		envSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: app.Spec.EnvSecretName, Namespace: app.Namespace}}
+		if err := r.Delete(ctx, envSecret); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("error deleting env secret: %w", err)
+		}
+	}
+
+	ofApp := []client.ListOption{client.InNamespace(app.Namespace), client.MatchingFields{indexes.AppRef: app.Name}}
+	if err := r.deleteAll(ctx, &cfappsv1alpha1.PackageList{}, ofApp...); err != nil {
+		return fmt.Errorf("error deleting packages: %w", err)
+	}
+	if err := r.deleteAll(ctx, &cfappsv1alpha1.BuildList{}, ofApp...); err != nil {
+		return fmt.Errorf("error deleting builds: %w", err)
+	}
+	if err := r.deleteAll(ctx, &buildv1alpha1.ImageList{}, client.InNamespace(app.Namespace), client.MatchingLabels{handlers.LabelAppGUID: app.Name}); err != nil {
+		return fmt.Errorf("error deleting kpack images: %w", err)
+	}
+	if err := r.deleteAll(ctx, &cfappsv1alpha1.DropletList{}, ofApp...); err != nil {
+		return fmt.Errorf("error deleting droplets: %w", err)
+	}
+	// kpack pushes every build of the App to the same tag, the droplets above only cover the digests still in use
+	if err := deletePlatformImage(ctx, r.KeychainFactory, app.Namespace, settings.GlobalSettings.RegistryTagBase+"/"+app.Name); err != nil {
+		return fmt.Errorf("error deleting build image: %w", err)
+	}
+
+	controllerutil.RemoveFinalizer(app, AppCleanupFinalizer)
+	if err := r.Update(ctx, app); err != nil {
+		return err
+	}
+	logger.Info("Cleaned up deleted app")
+	return nil
+}
+
+// reconcileNameLeases makes the App own the Lease reserving its name, so the Lease is deleted along with the App, and
+// deletes the Leases of the names it no longer has. A Lease taken in the grace period may be for a rename that is not
+// stored yet, it is left alone and the App is requeued after it. See validate.AppValidator
+func (r *AppReconciler) reconcileNameLeases(ctx context.Context, app *cfappsv1alpha1.App) (time.Duration, error) {
+	leases := &coordinationv1.LeaseList{}
+	if err := r.List(ctx, leases, client.InNamespace(app.Namespace), client.MatchingLabels{validate.AppNameHolderLabel: app.Name}); err != nil {
+		return 0, err
+	}
+
+	var requeueAfter time.Duration
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		if lease.Annotations[validate.AppNameAnnotation] == app.Spec.Name {
+			if len(lease.OwnerReferences) == 1 && lease.OwnerReferences[0].UID == app.UID {
+				continue
+			}
+			lease.OwnerReferences = []metav1.OwnerReference{cfappsv1alpha1.AppOwnerReference(app)}
+			if err := r.Update(ctx, lease); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if validate.IsRecentlyHeld(lease) {
+			requeueAfter = validate.AppNameLeaseGracePeriod
+			continue
+		}
+		// The Lease may have been taken over since it was listed, only delete the version that was read
+		err := r.Delete(ctx, lease, client.Preconditions{ResourceVersion: &lease.ResourceVersion})
+		if client.IgnoreNotFound(err) != nil && !apierrors.IsConflict(err) {
+			return 0, err
+		}
+	}
+	return requeueAfter, nil
+}
+
+// deleteAll deletes every object the list matches
+func (r *AppReconciler) deleteAll(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := r.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := r.Delete(ctx, item.(client.Object)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func processMutateFunction(actualProcess, desiredProcess *cfappsv1alpha1.Process) controllerutil.MutateFn {
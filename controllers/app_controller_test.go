@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/webhooks/validate"
+)
+
+func TestReconcileNameLeases(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = appsv1alpha1.AddToScheme(scheme)
+
+	app := &appsv1alpha1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-guid", Namespace: "space-guid", UID: "app-uid"},
+		Spec:       appsv1alpha1.AppSpec{Name: "my-app"},
+	}
+	newLease := func(appName, holder string, heldSince time.Time) *coordinationv1.Lease {
+		renewTime := metav1.NewMicroTime(heldSince)
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        validate.AppNameLockName(appName),
+				Namespace:   "space-guid",
+				Labels:      map[string]string{validate.AppNameHolderLabel: holder},
+				Annotations: map[string]string{validate.AppNameAnnotation: appName},
+			},
+			Spec: coordinationv1.LeaseSpec{HolderIdentity: &holder, RenewTime: &renewTime},
+		}
+	}
+	longAgo := time.Now().Add(-time.Hour)
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		app,
+		newLease("my-app", "app-guid", longAgo),
+		newLease("my-old-app", "app-guid", longAgo),
+		newLease("my-next-app", "app-guid", time.Now()),
+		newLease("other-app", "other-app-guid", longAgo),
+	).Build()
+	r := &AppReconciler{Client: kubeClient, Scheme: scheme}
+
+	requeueAfter, err := r.reconcileNameLeases(context.Background(), app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requeueAfter != validate.AppNameLeaseGracePeriod {
+		t.Errorf("expected a requeue after the grace period of the lease of a pending rename, got %v", requeueAfter)
+	}
+
+	getLease := func(appName string) (*coordinationv1.Lease, error) {
+		lease := &coordinationv1.Lease{}
+		err := kubeClient.Get(context.Background(), types.NamespacedName{Name: validate.AppNameLockName(appName), Namespace: "space-guid"}, lease)
+		return lease, err
+	}
+	lease, err := getLease("my-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lease.OwnerReferences) != 1 || lease.OwnerReferences[0].UID != "app-uid" {
+		t.Errorf("expected the app to own the lease of its name, got %+v", lease.OwnerReferences)
+	}
+	if _, err := getLease("my-old-app"); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the lease of the old name to be deleted, got %v", err)
+	}
+	if _, err := getLease("my-next-app"); err != nil {
+		t.Errorf("expected the lease of a pending rename to be kept, got %v", err)
+	}
+	if lease, err := getLease("other-app"); err != nil || len(lease.OwnerReferences) != 0 {
+		t.Errorf("expected the lease of another app to be left alone, got %v", err)
+	}
+}
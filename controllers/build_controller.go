@@ -19,19 +19,26 @@ package controllers
 import (
 	"cloudfoundry.org/cf-crd-explorations/cfshim/handlers"
 	"context"
-	"errors"
 	"fmt"
+	"reflect"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/types"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
 	cfappsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
@@ -39,17 +46,25 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"cloudfoundry.org/cf-crd-explorations/audit"
+	"cloudfoundry.org/cf-crd-explorations/indexes"
+	"cloudfoundry.org/cf-crd-explorations/metrics"
 	"cloudfoundry.org/cf-crd-explorations/settings"
+	"cloudfoundry.org/cf-crd-explorations/tracing"
 
 	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
 	buildcorev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
 	//corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
 )
 
+// stagingTimeout is how long a buildpack Build may stage before it fails, like the staging timeout of Cloud Controller
+const stagingTimeout = 15 * time.Minute
+
 // BuildReconciler reconciles a Build object
 type BuildReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=builds,verbs=get;list;watch;create;update;patch;delete
@@ -81,6 +96,8 @@ func (r *BuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		logger.Info(fmt.Sprintf("Error fetching build: %s", err))
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	ctx, span := tracing.StartReconcile(ctx, "BuildReconciler", &currentBuild, &currentBuild)
+	defer span.End()
 
 	// fetch the Build's App in order to grab things like environment variables for staging
 	// https://github.com/pivotal/kpack/blob/main/docs/image.md#build-configuration
@@ -98,254 +115,372 @@ func (r *BuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		return ctrl.Result{}, err
 	}
 
-	// Figure out if the status is succeeded True/False/Unknown
-	buildSucceededStatusValue := getConditionOrSetAsUnknown(&currentBuild.Status.Conditions, cfappsv1alpha1.SucceededConditionType)
-	buildStagingStatusValue := getConditionOrSetAsUnknown(&currentBuild.Status.Conditions, cfappsv1alpha1.StagingConditionType)
-
-	// Staging not-started flow:
-	//	Error and retry if package is empty
-	// 		Docker: set "Staging": "False"
-	//		Buildpack: create kpack Image, set "Staging": "True"
-	if buildSucceededStatusValue == metav1.ConditionUnknown &&
-		buildStagingStatusValue == metav1.ConditionUnknown {
-
-		// Package empty - return ctrl with err to force retry logic
-		// Indefinite retry - no exponential backoff implemented yet?
-		if cfappsv1alpha1.PackageType(buildPackage.Spec.Source.Registry.Image) == "" {
-			updateLocalConditionStatus(&currentBuild.Status.Conditions, cfappsv1alpha1.SucceededConditionType, metav1.ConditionUnknown, strings.Title(string(currentBuild.Spec.Type)), "packageRef package was empty")
+	originalBuild := currentBuild.DeepCopy()
+	// Builds created before they had a phase pick up where their conditions left off
+	if currentBuild.Status.Phase == "" {
+		currentBuild.Status.Phase = buildPhaseFromConditions(currentBuild.Status.Conditions)
+	}
+	startPhase := currentBuild.Status.Phase
+
+	result := ctrl.Result{}
+	switch currentBuild.Status.Phase {
+	// PENDING: wait for the package bits, then start staging
+	//		Docker: nothing to do, the package is the image
+	//		Buildpack: create kpack image
+	case cfappsv1alpha1.BuildPendingPhase:
+		// Package empty - wait for it, the Build is reconciled again when the Package is updated, see SetupWithManager
+		if buildPackage.Spec.Source.Registry.Image == "" {
+			setBuildPhase(&currentBuild, cfappsv1alpha1.BuildPendingPhase, nil)
 			updateLocalConditionStatus(&currentBuild.Status.Conditions, cfappsv1alpha1.ReadyConditionType, metav1.ConditionFalse, strings.Title(string(currentBuild.Spec.Type)), "packageRef package was empty")
+			break
+		}
 
-			var err error
-			if err = r.Status().Update(ctx, &currentBuild); err != nil {
-				logger.Error(err, "unable to update Build status")
-				logger.Info(fmt.Sprintf("Build status: %+v", currentBuild.Status))
-			} else {
-				err = errors.New("packageRef package was empty")
+		if currentBuild.Spec.Type == cfappsv1alpha1.BuildpackLifecycle {
+			if err := r.createKpackImage(ctx, &currentBuild, &app, &buildPackage); err != nil {
+				logger.Info(fmt.Sprintf("Error occurred updating kpack Image: %s", err))
+				return ctrl.Result{}, err
 			}
+		}
+		setBuildPhase(&currentBuild, cfappsv1alpha1.BuildStagingPhase, nil)
+		fallthrough
+
+	// STAGING: create the droplet once the image is ready
+	//		Docker: right away from the package
+	//		Buildpack: once the kpack build succeeded, fail if it failed or never completes
+	case cfappsv1alpha1.BuildStagingPhase:
+		var err error
+		if result, err = r.stage(ctx, &currentBuild, &app, &buildPackage); err != nil {
+			logger.Info(fmt.Sprintf("Error occurred staging build: %s", err))
 			return ctrl.Result{}, err
 		}
+	}
+	// STAGED and FAILED Builds are done
 
-		// For Docker type build staging, just move on to the droplet-creation stage by setting Condition "Staging": "False"
-		if currentBuild.Spec.Type == cfappsv1alpha1.DockerLifecycle {
-			updateLocalConditionStatus(&currentBuild.Status.Conditions, cfappsv1alpha1.ReadyConditionType, metav1.ConditionFalse, "Docker", "")
-			updateLocalConditionStatus(&currentBuild.Status.Conditions, cfappsv1alpha1.StagingConditionType, metav1.ConditionFalse, "Docker", "")
-
-			// For Buildpack type build staging, we need to create a kpack image
-		} else if currentBuild.Spec.Type == cfappsv1alpha1.BuildpackLifecycle {
-			kpackImageName := "cf-build-" + currentBuild.Name
-			kpackImageNamespace := currentBuild.Namespace
-			// make a desired kpack CR
-			desiredKpackImage := buildv1alpha1.Image{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      kpackImageName,
-					Namespace: kpackImageNamespace,
-					Labels: map[string]string{
-						"apps.cloudfoundry.org/buildGuid": currentBuild.Name,
-						handlers.LabelAppGUID:             app.GetName(),
-					},
-				},
-				Spec: buildv1alpha1.ImageSpec{
-					Tag: settings.GlobalSettings.RegistryTagBase + "/" + app.GetName(),
-					Builder: corev1.ObjectReference{
-						Kind:       "ClusterBuilder",
-						Name:       "my-sample-builder", // TODO: cf-for-k8s makes a builder per-app
-						APIVersion: "kpack.io/v1alpha1",
-					},
-					ServiceAccount: "kpack-service-account", // TODO: this is hardcoded too! You need a serviceAccount w/ secrets with this name in every namespace you build in.
-					Source: buildv1alpha1.SourceConfig{
-						Registry: &buildv1alpha1.Registry{
-							Image:            buildPackage.Spec.Source.Registry.Image,
-							ImagePullSecrets: buildPackage.Spec.Source.Registry.ImagePullSecrets,
-						},
-						SubPath: "",
-					},
-				},
-			}
-			// actualImage is used by the function below to look up if we created an kpack image for this cf build already
-			actualImage := &buildv1alpha1.Image{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      kpackImageName,
-					Namespace: kpackImageNamespace,
-				},
-			}
-			// Actually create or update the kpack Image with K8s client
-			result, err := controllerutil.CreateOrUpdate(ctx, r.Client, actualImage, cfBuildMutateFunction(actualImage, &desiredKpackImage))
-			if err != nil {
-				logger.Info(fmt.Sprintf("Error occurred updating kpack Image: %s, %s", result, err))
-				return ctrl.Result{}, err
-			}
-			// after successfully creating kpack image, update the status of the build CR
-			updateLocalConditionStatus(&currentBuild.Status.Conditions, cfappsv1alpha1.StagingConditionType, metav1.ConditionTrue, "Buildpack", "")
-			updateLocalConditionStatus(&currentBuild.Status.Conditions, cfappsv1alpha1.ReadyConditionType, metav1.ConditionFalse, "Buildpack", "")
+	if !reflect.DeepEqual(originalBuild.Status, currentBuild.Status) {
+		if err := r.Status().Update(ctx, &currentBuild); err != nil {
+			logger.Error(err, "unable to update Build status")
+			logger.Info(fmt.Sprintf("Build status: %+v", currentBuild.Status))
+			return ctrl.Result{}, err
 		}
-		// END "Succeeded" == "Unknown" && "Staging" == "Unknown"
-
-		// Staging complete("Succeeded" == "Unknown" && "Staging" == "False") flow:
-		// 		Docker: create droplet from package
-		//		Buildpack: create droplet from kpack build
-	} else if buildSucceededStatusValue == metav1.ConditionUnknown &&
-		buildStagingStatusValue == metav1.ConditionFalse {
-
-		// These fields will be used to create the droplet later, dropletImageRegistry is made differently for Buildpack builds
-		buildSucceeded := metav1.ConditionUnknown
-		dropletName := currentBuild.Name
-		dropletNamespace := currentBuild.Namespace
-		dropletImageRegistry := cfappsv1alpha1.Registry{
-			Image:            buildPackage.Spec.Source.Registry.Image,
-			ImagePullSecrets: buildPackage.Spec.Source.Registry.ImagePullSecrets,
+	}
+	r.recordPhaseEvents(&currentBuild, startPhase)
+	observePhaseMetrics(&currentBuild, startPhase)
+	return result, nil
+}
+
+// recordPhaseEvents records the phases a Build moved through since startPhase, a docker Build goes from PENDING to
+// STAGED in a single reconcile
+func (r *BuildReconciler) recordPhaseEvents(currentBuild *cfappsv1alpha1.Build, startPhase cfappsv1alpha1.BuildPhase) {
+	phase := currentBuild.Status.Phase
+	if phase == startPhase {
+		return
+	}
+	if startPhase == cfappsv1alpha1.BuildPendingPhase {
+		r.Recorder.Eventf(currentBuild, corev1.EventTypeNormal, StagingStartedReason, "Started staging package %s", currentBuild.Spec.PackageRef.Name)
+	}
+	if phase == cfappsv1alpha1.BuildStagedPhase {
+		r.Recorder.Eventf(currentBuild, corev1.EventTypeNormal, StagingSucceededReason, "Staged droplet %s", currentBuild.Status.DropletReference.Name)
+	} else if phase == cfappsv1alpha1.BuildFailedPhase {
+		r.Recorder.Eventf(currentBuild, corev1.EventTypeWarning, StagingFailedReason, "%s: %s", currentBuild.Status.Error.Title, currentBuild.Status.Error.Detail)
+	}
+}
+
+// observePhaseMetrics observes the staging duration and failure of a Build that became STAGED or FAILED since startPhase
+func observePhaseMetrics(currentBuild *cfappsv1alpha1.Build, startPhase cfappsv1alpha1.BuildPhase) {
+	phase := currentBuild.Status.Phase
+	if phase == startPhase || (phase != cfappsv1alpha1.BuildStagedPhase && phase != cfappsv1alpha1.BuildFailedPhase) {
+		return
+	}
+	if currentBuild.Status.StagingDuration != nil {
+		metrics.StagingDuration.WithLabelValues(string(currentBuild.Spec.Type), strings.ToLower(string(phase))).
+			Observe(currentBuild.Status.StagingDuration.Seconds())
+	}
+	if phase == cfappsv1alpha1.BuildFailedPhase && currentBuild.Status.Error != nil {
+		metrics.BuildFailures.WithLabelValues(currentBuild.Status.Error.Title).Inc()
+	}
+}
+
+// createKpackImage creates the kpack Image that stages a buildpack Build, kpack starts a kpack Build for it
+func (r *BuildReconciler) createKpackImage(ctx context.Context, currentBuild *cfappsv1alpha1.Build, app *cfappsv1alpha1.App, buildPackage *cfappsv1alpha1.Package) error {
+	kpackImageName := "cf-build-" + currentBuild.Name
+	kpackImageNamespace := currentBuild.Namespace
+	// make a desired kpack CR
+	desiredKpackImage := buildv1alpha1.Image{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kpackImageName,
+			Namespace: kpackImageNamespace,
+			Labels: map[string]string{
+				BuildGUIDLabel:        currentBuild.Name,
+				handlers.LabelAppGUID: app.GetName(),
+			},
+		},
+		Spec: buildv1alpha1.ImageSpec{
+			Tag: settings.GlobalSettings.RegistryTagBase + "/" + app.GetName(),
+			Builder: corev1.ObjectReference{
+				Kind:       "ClusterBuilder",
+				Name:       "my-sample-builder", // TODO: cf-for-k8s makes a builder per-app
+				APIVersion: "kpack.io/v1alpha1",
+			},
+			ServiceAccount: "kpack-service-account", // TODO: this is hardcoded too! You need a serviceAccount w/ secrets with this name in every namespace you build in.
+			Source: buildv1alpha1.SourceConfig{
+				Registry: &buildv1alpha1.Registry{
+					Image:            buildPackage.Spec.Source.Registry.Image,
+					ImagePullSecrets: buildPackage.Spec.Source.Registry.ImagePullSecrets,
+				},
+				SubPath: "",
+			},
+		},
+	}
+	// The CF Build owns its kpack Image, and with it the kpack Builds, so they go away with the Build
+	if err := controllerutil.SetControllerReference(currentBuild, &desiredKpackImage, r.Scheme); err != nil {
+		return err
+	}
+	// actualImage is used by the function below to look up if we created an kpack image for this cf build already
+	actualImage := &buildv1alpha1.Image{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kpackImageName,
+			Namespace: kpackImageNamespace,
+		},
+	}
+	// Actually create or update the kpack Image with K8s client
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, actualImage, cfBuildMutateFunction(actualImage, &desiredKpackImage))
+	return err
+}
+
+// stage moves a STAGING Build to STAGED once its droplet is created, or to FAILED
+// A buildpack Build waits for its kpack build, it is requeued to time out in case the kpack build never completes
+func (r *BuildReconciler) stage(ctx context.Context, currentBuild *cfappsv1alpha1.Build, app *cfappsv1alpha1.App, buildPackage *cfappsv1alpha1.Package) (ctrl.Result, error) {
+	// dropletImageRegistry is constructed from the Package for Docker type, and created from the kpack build from Buildpack type
+	dropletImageRegistry := cfappsv1alpha1.Registry{
+		Image:            buildPackage.Spec.Source.Registry.Image,
+		ImagePullSecrets: buildPackage.Spec.Source.Registry.ImagePullSecrets,
+	}
+
+	if currentBuild.Spec.Type == cfappsv1alpha1.BuildpackLifecycle {
+		// look up the kpack Build CR of the kpack Image we created for the CF build CR
+		kpackBuild, err := latestKpackBuild(ctx, r.Client, currentBuild)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("error fetching kpack build: %w", err)
 		}
 
-		if currentBuild.Spec.Type == cfappsv1alpha1.DockerLifecycle {
-			// Package empty - return ctrl with err to force retry logic
-			// Indefinite retry - no exponential backoff implemented yet?
-			if cfappsv1alpha1.PackageType(buildPackage.Spec.Source.Registry.Image) == "" {
-				updateLocalConditionStatus(&currentBuild.Status.Conditions, cfappsv1alpha1.SucceededConditionType, metav1.ConditionUnknown, "Docker", "packageRef package was empty")
-				updateLocalConditionStatus(&currentBuild.Status.Conditions, cfappsv1alpha1.ReadyConditionType, metav1.ConditionFalse, "Docker", "packageRef package was empty")
-
-				var err error
-				if err = r.Status().Update(ctx, &currentBuild); err != nil {
-					logger.Error(err, "unable to update Build status")
-					logger.Info(fmt.Sprintf("Build status: %+v", currentBuild.Status))
-				} else {
-					err = errors.New("packageRef package was empty")
-				}
-				return ctrl.Result{}, err
+		kpackBuildSucceeded := metav1.ConditionUnknown
+		if kpackBuild != nil {
+			currentBuild.Status.KpackBuildReference = cfappsv1alpha1.KpackBuildReference{
+				Kind:       "Build",
+				APIVersion: buildv1alpha1.SchemeGroupVersion.String(),
+				Name:       kpackBuild.Name,
 			}
-			buildSucceeded = metav1.ConditionTrue
-		} else if currentBuild.Spec.Type == cfappsv1alpha1.BuildpackLifecycle {
-			// look up the kpack Build CR based on the the CF build CR
-			var kpackBuild buildv1alpha1.Build
-			// fetch the list of kpack builds with the labels set on the kpack image we created earlier
-			kpackBuildList := &buildv1alpha1.BuildList{}
-			err := r.Client.List(context.Background(), kpackBuildList, client.InNamespace(currentBuild.Namespace), client.MatchingLabels{handlers.LabelAppGUID: app.GetName()})
-			if err != nil || len(kpackBuildList.Items) == 0 {
-				logger.Info(fmt.Sprintf("Error fetching kpack build for %s", app.GetName()))
-				return ctrl.Result{}, err
-			}
-			kpackBuild = kpackBuildList.Items[0]
+			kpackBuildSucceeded = kpackConditionStatus(kpackBuild.Status.GetCondition(buildcorev1alpha1.ConditionSucceeded))
+		}
 
-			// If the kpack build failed, update the CF Build CR statuses and flip the flag buildSucceeded so that a Droplet will not be created
-			kpackBuildSucceededStatus := getKpackBuildCondition(&kpackBuild.Status, "Succeeded")
-			if kpackBuildSucceededStatus != nil {
-				buildSucceeded = stringToConditionStatus(string(kpackBuildSucceededStatus.Status))
+		switch kpackBuildSucceeded {
+		case metav1.ConditionUnknown:
+			stagingStarted := currentBuild.CreationTimestamp
+			if currentBuild.Status.StartedAt != nil {
+				stagingStarted = *currentBuild.Status.StartedAt
 			}
-			// If the kpack build status is unknown, then retry later and return an error
-			if buildSucceeded == metav1.ConditionUnknown {
-				logger.Error(err, "unable to update Build status due to unknown kpack build status while Staging: False")
-				return ctrl.Result{}, err
-				// if the kpack build succeeded we need to create the dropletImageRegistry using the kpack build's details
-			} else if buildSucceeded == metav1.ConditionTrue {
-				dropletImageRegistry = cfappsv1alpha1.Registry{
-					Image: kpackBuild.Status.LatestImage,
-					// TODO: Ask kpack team which secrets get used to push the build- builder secret or the source image secret?
-					ImagePullSecrets: kpackBuild.Spec.Source.Registry.ImagePullSecrets,
-				}
+			if staging := time.Since(stagingStarted.Time); staging < stagingTimeout {
+				return ctrl.Result{RequeueAfter: stagingTimeout - staging}, nil
 			}
+			setBuildPhase(currentBuild, cfappsv1alpha1.BuildFailedPhase, stagingTimeExpiredError(stagingTimeout))
+			return ctrl.Result{}, nil
+		case metav1.ConditionFalse:
+			setBuildPhase(currentBuild, cfappsv1alpha1.BuildFailedPhase, stagingError(kpackBuildFailureMessage(kpackBuild)))
+			return ctrl.Result{}, nil
 		}
 
-		// buildSucceeded is usually "True" from Docker type, it is derived from kpack build from Buildpack type
-		// dropletImageRegistry is constructed from the Package for Docker type, and created from the kpack build from Buildpack type
-		if buildSucceeded == metav1.ConditionTrue {
-			desiredDroplet := cfappsv1alpha1.Droplet{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "Droplet",
-					APIVersion: currentBuild.APIVersion,
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      dropletName,
-					Namespace: dropletNamespace,
-					Labels: map[string]string{
-						handlers.LabelBuildGUID: currentBuild.Name,
-						handlers.LabelAppGUID:   app.GetName(),
-					},
-				},
-				Spec: cfappsv1alpha1.DropletSpec{
-					Type:   currentBuild.Spec.Type,
-					AppRef: currentBuild.Spec.AppRef,
-					BuildRef: cfappsv1alpha1.BuildReference{
-						Kind:       "Build",
-						APIVersion: currentBuild.APIVersion,
-						Name:       currentBuild.Name,
-					},
-					Registry: dropletImageRegistry,
-				},
-				Status: cfappsv1alpha1.DropletStatus{
-					// TODO: Type is always KpackImageReference - should this have a different type for Docker images?
-					ImageRef: cfappsv1alpha1.KpackImageReference{
-						Kind:       buildPackage.Kind,
-						APIVersion: buildPackage.APIVersion,
-						Name:       buildPackage.Name,
-					},
-				},
-			}
-			actualDroplet := &v1alpha1.Droplet{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      dropletName,
-					Namespace: dropletNamespace,
-				},
-			}
-			// Create or update the Droplet with K8s client
-			result, err := controllerutil.CreateOrUpdate(ctx, r.Client, actualDroplet, dropletMutateFunction(actualDroplet, &desiredDroplet))
-			if err != nil {
-				// TODO: Update build conditions when droplet push fails?
-				logger.Info(fmt.Sprintf("Error occurred updating Droplet: %s, %s", result, err))
-				return ctrl.Result{}, err
-			}
+		dropletImageRegistry = cfappsv1alpha1.Registry{
+			Image: kpackBuild.Status.LatestImage,
+			// TODO: Ask kpack team which secrets get used to push the build- builder secret or the source image secret?
+			ImagePullSecrets: kpackBuild.Spec.Source.Registry.ImagePullSecrets,
+		}
+	}
 
-			currentBuild.Status.DropletReference = cfappsv1alpha1.DropletReference{
-				Kind:       desiredDroplet.Kind,
-				APIVersion: desiredDroplet.APIVersion,
-				Name:       desiredDroplet.Name,
-			}
+	dropletName := currentBuild.Name
+	dropletNamespace := currentBuild.Namespace
+	desiredDroplet := cfappsv1alpha1.Droplet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Droplet",
+			APIVersion: currentBuild.APIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dropletName,
+			Namespace: dropletNamespace,
+			Labels: map[string]string{
+				handlers.LabelBuildGUID: currentBuild.Name,
+				handlers.LabelAppGUID:   app.GetName(),
+			},
+			// The Droplet outlives its Build, so it is owned by the App instead
+			OwnerReferences: []metav1.OwnerReference{
+				cfappsv1alpha1.AppOwnerReference(app),
+			},
+		},
+		Spec: cfappsv1alpha1.DropletSpec{
+			Type:   currentBuild.Spec.Type,
+			AppRef: currentBuild.Spec.AppRef,
+			BuildRef: cfappsv1alpha1.BuildReference{
+				Kind:       "Build",
+				APIVersion: currentBuild.APIVersion,
+				Name:       currentBuild.Name,
+			},
+			Registry: dropletImageRegistry,
+		},
+		Status: cfappsv1alpha1.DropletStatus{
+			// TODO: Type is always KpackImageReference - should this have a different type for Docker images?
+			ImageRef: cfappsv1alpha1.KpackImageReference{
+				Kind:       buildPackage.Kind,
+				APIVersion: buildPackage.APIVersion,
+				Name:       buildPackage.Name,
+			},
+		},
+	}
+	// The DropletReconciler continues the trace of the staging that created the Droplet
+	tracing.InjectAnnotations(ctx, &desiredDroplet)
+	actualDroplet := &v1alpha1.Droplet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dropletName,
+			Namespace: dropletNamespace,
+		},
+	}
+	// Create or update the Droplet with K8s client
+	// TODO: Update build conditions when droplet push fails?
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, actualDroplet, dropletMutateFunction(actualDroplet, &desiredDroplet))
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("error creating droplet: %w", err)
+	}
+	if result == controllerutil.OperationResultCreated {
+		r.Recorder.Eventf(currentBuild, corev1.EventTypeNormal, DropletCreatedReason, "Created droplet %s", dropletName)
+		r.Recorder.Eventf(app, corev1.EventTypeNormal, DropletCreatedReason, "Created droplet %s from build %s", dropletName, currentBuild.Name)
+		// The droplet is created once, so a failure to record it is only logged rather than retried
+		err = audit.Record(ctx, r.Client, app.Namespace, audit.AppDropletCreate, audit.SystemActor, audit.AppTarget(app), map[string]string{
+			"droplet_guid": dropletName,
+			"build_guid":   currentBuild.Name,
+		})
+		if err != nil {
+			log.FromContext(ctx).Error(err, "error recording audit event", "type", audit.AppDropletCreate)
 		}
+	}
 
-		updateLocalConditionStatus(&currentBuild.Status.Conditions, cfappsv1alpha1.SucceededConditionType, buildSucceeded, strings.Title(string(currentBuild.Spec.Type)), "")
-		updateLocalConditionStatus(&currentBuild.Status.Conditions, cfappsv1alpha1.ReadyConditionType, buildSucceeded, strings.Title(string(currentBuild.Spec.Type)), "")
+	currentBuild.Status.DropletReference = cfappsv1alpha1.DropletReference{
+		Kind:       desiredDroplet.Kind,
+		APIVersion: desiredDroplet.APIVersion,
+		Name:       desiredDroplet.Name,
+	}
+	setBuildPhase(currentBuild, cfappsv1alpha1.BuildStagedPhase, nil)
+	return ctrl.Result{}, nil
+}
 
+// setBuildPhase moves a Build to the phase and records when staging started and finished
+// The Staging, Succeeded and Ready conditions follow the phase for consumers that still read them
+func setBuildPhase(currentBuild *cfappsv1alpha1.Build, phase cfappsv1alpha1.BuildPhase, buildError *cfappsv1alpha1.BuildError) {
+	now := metav1.Now()
+	reason := strings.Title(string(currentBuild.Spec.Type))
+	message := ""
+	if buildError != nil {
+		reason = strings.TrimPrefix(buildError.Title, "CF-")
+		message = buildError.Detail
 	}
 
-	// Update Build Status Conditions based on changes made to local copy
-	if err := r.Status().Update(ctx, &currentBuild); err != nil {
-		logger.Error(err, "unable to update Build status")
-		logger.Info(fmt.Sprintf("Build status: %+v", currentBuild.Status))
-		return ctrl.Result{}, err
+	currentBuild.Status.Phase = phase
+	currentBuild.Status.Error = buildError
+	conditions := &currentBuild.Status.Conditions
+	switch phase {
+	case cfappsv1alpha1.BuildPendingPhase:
+		updateLocalConditionStatus(conditions, cfappsv1alpha1.StagingConditionType, metav1.ConditionUnknown, reason, message)
+		updateLocalConditionStatus(conditions, cfappsv1alpha1.SucceededConditionType, metav1.ConditionUnknown, reason, message)
+		updateLocalConditionStatus(conditions, cfappsv1alpha1.ReadyConditionType, metav1.ConditionFalse, reason, message)
+	case cfappsv1alpha1.BuildStagingPhase:
+		currentBuild.Status.StartedAt = &now
+		updateLocalConditionStatus(conditions, cfappsv1alpha1.StagingConditionType, metav1.ConditionTrue, reason, message)
+		updateLocalConditionStatus(conditions, cfappsv1alpha1.SucceededConditionType, metav1.ConditionUnknown, reason, message)
+		updateLocalConditionStatus(conditions, cfappsv1alpha1.ReadyConditionType, metav1.ConditionFalse, reason, message)
+	case cfappsv1alpha1.BuildStagedPhase, cfappsv1alpha1.BuildFailedPhase:
+		succeeded := metav1.ConditionFalse
+		if phase == cfappsv1alpha1.BuildStagedPhase {
+			succeeded = metav1.ConditionTrue
+		}
+		currentBuild.Status.FinishedAt = &now
+		if currentBuild.Status.StartedAt != nil {
+			currentBuild.Status.StagingDuration = &metav1.Duration{Duration: now.Sub(currentBuild.Status.StartedAt.Time)}
+		}
+		updateLocalConditionStatus(conditions, cfappsv1alpha1.StagingConditionType, metav1.ConditionFalse, reason, message)
+		updateLocalConditionStatus(conditions, cfappsv1alpha1.SucceededConditionType, succeeded, reason, message)
+		updateLocalConditionStatus(conditions, cfappsv1alpha1.ReadyConditionType, succeeded, reason, message)
+	}
+}
+
+// buildPhaseFromConditions derives the phase of a Build from the conditions it was staged with before it had one
+func buildPhaseFromConditions(conditions []metav1.Condition) cfappsv1alpha1.BuildPhase {
+	if meta.IsStatusConditionTrue(conditions, cfappsv1alpha1.SucceededConditionType) {
+		return cfappsv1alpha1.BuildStagedPhase
+	} else if meta.IsStatusConditionFalse(conditions, cfappsv1alpha1.SucceededConditionType) {
+		return cfappsv1alpha1.BuildFailedPhase
+	} else if staging := meta.FindStatusCondition(conditions, cfappsv1alpha1.StagingConditionType); staging != nil && staging.Status != metav1.ConditionUnknown {
+		return cfappsv1alpha1.BuildStagingPhase
+	}
+	return cfappsv1alpha1.BuildPendingPhase
+}
+
+// stagingError and stagingTimeExpiredError are the staging errors of Cloud Controller a Build can fail with
+func stagingError(detail string) *cfappsv1alpha1.BuildError {
+	return &cfappsv1alpha1.BuildError{
+		Code:   170001,
+		Title:  "CF-StagingError",
+		Detail: "Staging error: " + detail,
+	}
+}
+
+func stagingTimeExpiredError(timeout time.Duration) *cfappsv1alpha1.BuildError {
+	return &cfappsv1alpha1.BuildError{
+		Code:   170007,
+		Title:  "CF-StagingTimeExpired",
+		Detail: fmt.Sprintf("Staging time expired: staging did not complete within %s", timeout),
 	}
-	return ctrl.Result{}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
+// The CF Build owns its kpack Image, and kpack Builds carry the GUID of the CF Build in a label, so a Build is
+// reconciled again whenever kpack makes progress on it. A pending Build is reconciled again once its Package has bits
 func (r *BuildReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&cfappsv1alpha1.Build{}).
+		Owns(&buildv1alpha1.Image{}).
+		Watches(&source.Kind{Type: &buildv1alpha1.Build{}}, handler.EnqueueRequestsFromMapFunc(kpackBuildToCFBuild),
+			builder.WithPredicates(predicate.NewPredicateFuncs(buildFilter))).
+		Watches(&source.Kind{Type: &cfappsv1alpha1.Package{}}, handler.EnqueueRequestsFromMapFunc(func(pk client.Object) []reconcile.Request {
+			buildList := &cfappsv1alpha1.BuildList{}
+			_ = mgr.GetClient().List(context.Background(), buildList, client.InNamespace(pk.GetNamespace()), client.MatchingFields{indexes.BuildPackageRef: pk.GetName()})
+			var requests []reconcile.Request
+
+			for _, build := range buildList.Items {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      build.Name,
+						Namespace: build.Namespace,
+					},
+				})
+			}
+			return requests
+		})).
 		Complete(r)
 }
 
-// watch kpack builds
-// see kpack build event. if kpack build in a terminal state (completed or failed) add enqueue CF Build "request"
-// reconcile the CF Build "request" we just enqueued
-// look up kpack build while reconciling
+// buildpack Build Phase
+// PENDING
+//		-> build reconciler makes kpack image, STAGING
+//			-> kpack image makes kpack build(still STAGING)
+//				-> kpack build completes, build reconciler creates droplet and moves to STAGED, or FAILED
+//				-> kpack build never completes, build reconciler moves to FAILED after stagingTimeout
 
-// KpackBuildCFBuildReconciler flow:
-// watch kpack builds
-// see kpack build is in terminal state
-// update status of associated build
-
-// buildpack Build Status
-// created(staging)
-//		-> build reconciler makes kpack image(still staging)
-//			-> kpack image makes kpack build(still staging)
-//				-> kpack build completes, kpack build reconciler updates build status (staged/failed)
-// 					-> build reconciler creates droplet if state == staged
-
-// docker Build Status
-// created(staging)
-//		-> build reconciler just changes status (staging->staged) and exits
-//			-> build reconciler creates droplet if state == staged
+// docker Build Phase
+// PENDING
+//		-> build reconciler moves to STAGING, creates droplet from the package and moves to STAGED in one go
 
 // The Mutate function is for only updating the fields we care about for the update CR case
 func cfBuildMutateFunction(actualImage, desiredImage *buildv1alpha1.Image) controllerutil.MutateFn {
 	return func() error {
 		actualImage.ObjectMeta.Labels = desiredImage.ObjectMeta.Labels
+		actualImage.ObjectMeta.OwnerReferences = desiredImage.ObjectMeta.OwnerReferences
 		actualImage.Spec.Tag = desiredImage.Spec.Tag
 		actualImage.Spec.Builder = desiredImage.Spec.Builder
 		actualImage.Spec.ServiceAccount = desiredImage.Spec.ServiceAccount
@@ -357,6 +492,10 @@ func cfBuildMutateFunction(actualImage, desiredImage *buildv1alpha1.Image) contr
 func dropletMutateFunction(actualDroplet, desiredDroplet *v1alpha1.Droplet) controllerutil.MutateFn {
 	return func() error {
 		actualDroplet.ObjectMeta.Labels = desiredDroplet.ObjectMeta.Labels
+		if actualDroplet.CreationTimestamp.IsZero() {
+			actualDroplet.ObjectMeta.Annotations = desiredDroplet.ObjectMeta.Annotations
+		}
+		actualDroplet.ObjectMeta.OwnerReferences = desiredDroplet.ObjectMeta.OwnerReferences
 		actualDroplet.Spec.Type = desiredDroplet.Spec.Type
 		actualDroplet.Spec.AppRef = desiredDroplet.Spec.AppRef
 		actualDroplet.Spec.BuildRef = desiredDroplet.Spec.BuildRef
@@ -366,33 +505,12 @@ func dropletMutateFunction(actualDroplet, desiredDroplet *v1alpha1.Droplet) cont
 	}
 }
 
-// getConditionOrSetAsUnknown is a helper function that retrieves the value of the provided conditionType, like "Succeeded" and returns the value: "True", "False", or "Unknown"
-//	if the value is not present, the pointer to the list of conditions provided to the function is used to add an entry to the list of Conditions with a value of "Unknown" and "Unknown" is returned
-func getConditionOrSetAsUnknown(conditions *[]metav1.Condition, conditionType string) metav1.ConditionStatus {
-	conditionStatus := meta.FindStatusCondition(*conditions, conditionType)
-	conditionStatusValue := metav1.ConditionUnknown // enum for "Unknown"
-	if conditionStatus != nil {
-		conditionStatusValue = conditionStatus.Status
-	} else {
-		// set local copy of CR condition "succeeded": "unknown" because it had no value before
-		meta.SetStatusCondition(conditions, metav1.Condition{
-			Type:    conditionType,
-			Status:  metav1.ConditionUnknown,
-			Reason:  "NotReady", // TODO: Think about this. Consumers of status will care?
-			Message: "",
-		})
-	}
-	return conditionStatusValue
-}
-
-//func getCondition(buildv1alpha1.)
-func getKpackBuildCondition(s *buildv1alpha1.BuildStatus, t string) *buildcorev1alpha1.Condition {
-	for _, cond := range s.Conditions {
-		if string(cond.Type) == t {
-			return &cond
-		}
+// kpackConditionStatus converts a kpack condition to its metav1 status, a condition kpack has not set yet is Unknown
+func kpackConditionStatus(condition *buildcorev1alpha1.Condition) metav1.ConditionStatus {
+	if condition == nil {
+		return metav1.ConditionUnknown
 	}
-	return nil
+	return stringToConditionStatus(string(condition.Status))
 }
 
 func stringToConditionStatus(s string) metav1.ConditionStatus {
@@ -22,28 +22,37 @@ import (
 	"fmt"
 	"github.com/buildpacks/lifecycle/launch"
 	"github.com/buildpacks/lifecycle/platform"
-	"github.com/go-logr/logr"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/pivotal/kpack/pkg/registry"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/indexes"
+	"cloudfoundry.org/cf-crd-explorations/tracing"
 )
 
+// DropletCleanupFinalizer holds a deleted Droplet until the image kpack pushed for it is deleted
+const DropletCleanupFinalizer = "apps.cloudfoundry.org/droplet-cleanup"
+
 // DropletReconciler reconciles a Droplet object
 type DropletReconciler struct {
 	client.Client
 	Scheme          *runtime.Scheme
+	Recorder        record.EventRecorder
 	KeychainFactory registry.KeychainFactory
 }
 
@@ -75,10 +84,45 @@ func (r *DropletReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		logger.Info(fmt.Sprintf("Error fetching droplet: %s", err))
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	ctx, span := tracing.StartReconcile(ctx, "DropletReconciler", &droplet, &droplet)
+	defer span.End()
+
+	if !droplet.DeletionTimestamp.IsZero() {
+		err := r.cleanupDroplet(ctx, &droplet)
+		if err != nil {
+			r.Recorder.Event(&droplet, corev1.EventTypeWarning, CleanupFailedReason, err.Error())
+		}
+		return ctrl.Result{}, err
+	}
+	if !controllerutil.ContainsFinalizer(&droplet, DropletCleanupFinalizer) {
+		controllerutil.AddFinalizer(&droplet, DropletCleanupFinalizer)
+		if err := r.Update(ctx, &droplet); err != nil {
+			logger.Info(fmt.Sprintf("Error adding finalizer to droplet: %s", err))
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Once pinned, always read the droplet from its digest so a re-pushed tag cannot change what it describes
+	imageRef := droplet.Spec.Registry.Image
+	if droplet.Status.ResolvedImage != "" {
+		imageRef = droplet.Status.ResolvedImage
+	}
+
+	imageConfig, resolvedImage, err := r.fetchImageConfig(ctx, imageRef, droplet.Spec.Registry.ImagePullSecrets, droplet.Namespace)
+	if err != nil {
+		logger.Info(fmt.Sprintf("Error fetching image config: %s", err))
+		return ctrl.Result{}, err
+	}
 
 	// Extract Process and Command info from build
 	// Should we do this on every reconcile?
-	processCommandMap, exposedPorts, err := r.extractImageConfig(ctx, logger, droplet.Spec.Registry, droplet.Namespace)
+	var processCommandMap map[string]string
+	var exposedPorts []int32
+	if droplet.Spec.Type == appsv1alpha1.DockerLifecycle {
+		processCommandMap, exposedPorts, err = extractDockerImageConfig(imageConfig)
+	} else {
+		processCommandMap, exposedPorts, err = extractBuildpackImageConfig(imageConfig)
+	}
 	if err != nil {
 		logger.Info(fmt.Sprintf("Error occurred extracting process types and commands: %s", err))
 		return ctrl.Result{}, err
@@ -95,9 +139,72 @@ func (r *DropletReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
+	originalDroplet := updatedDroplet.DeepCopy()
+	updatedDroplet.Status.ResolvedImage = resolvedImage
+
+	// Docker images carry no buildpack metadata, so record what we detected from the image the way Cloud Controller does
+	if droplet.Spec.Type == appsv1alpha1.DockerLifecycle {
+		executionMetadata, err := dockerExecutionMetadata(imageConfig, exposedPorts)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		updatedDroplet.Status.LifecycleData.ExecutionMetadata = executionMetadata
+	}
+
+	if !reflect.DeepEqual(originalDroplet.Status, updatedDroplet.Status) {
+		err = r.Client.Status().Patch(ctx, updatedDroplet, client.MergeFrom(originalDroplet))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		logger.Info(fmt.Sprintf("Pinned droplet image to %s", resolvedImage))
+		r.Recorder.Eventf(updatedDroplet, corev1.EventTypeNormal, ImageResolvedReason, "Pinned droplet image to %s", resolvedImage)
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// cleanupDroplet deletes the image digest a buildpack Droplet was pinned to, images of docker Droplets belong to the user
+// Buildpack builds are reproducible, so restaging a package can pin another Droplet of the App to the same digest,
+// which is kept for as long as such a Droplet remains
+func (r *DropletReconciler) cleanupDroplet(ctx context.Context, droplet *appsv1alpha1.Droplet) error {
+	if !controllerutil.ContainsFinalizer(droplet, DropletCleanupFinalizer) {
+		return nil
+	}
+
+	inUse, err := r.imageInUse(ctx, droplet)
+	if err != nil {
+		return err
+	}
+	if !inUse {
+		if err := deletePlatformImage(ctx, r.KeychainFactory, droplet.Namespace, droplet.Status.ResolvedImage); err != nil {
+			return fmt.Errorf("error deleting droplet image: %w", err)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(droplet, DropletCleanupFinalizer)
+	return r.Update(ctx, droplet)
+}
+
+// imageInUse is true if another Droplet in the namespace that is not being deleted is pinned to the image of droplet
+func (r *DropletReconciler) imageInUse(ctx context.Context, droplet *appsv1alpha1.Droplet) (bool, error) {
+	if droplet.Status.ResolvedImage == "" {
+		return false, nil
+	}
+
+	droplets := &appsv1alpha1.DropletList{}
+	err := r.List(ctx, droplets, client.InNamespace(droplet.Namespace), client.MatchingFields{indexes.DropletResolvedImage: droplet.Status.ResolvedImage})
+	if err != nil {
+		return false, fmt.Errorf("error fetching droplets: %w", err)
+	}
+	for i := range droplets.Items {
+		other := &droplets.Items[i]
+		if other.Name != droplet.Name && other.DeletionTimestamp.IsZero() && other.Status.ResolvedImage == droplet.Status.ResolvedImage {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *DropletReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -105,12 +212,12 @@ func (r *DropletReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
-// fetch the Image Configuration Spec from the OCI image
+// fetch the Image Configuration Spec from the OCI image along with the immutable digest reference of its manifest
 // See: https://github.com/opencontainers/image-spec/blob/main/config.md
-func (r *DropletReconciler) fetchImageConfig(ctx context.Context, imageRef string, imagePullSecrets []corev1.LocalObjectReference, ns string) (*v1.Config, error) {
+func (r *DropletReconciler) fetchImageConfig(ctx context.Context, imageRef string, imagePullSecrets []corev1.LocalObjectReference, ns string) (*v1.Config, string, error) {
 	ref, err := name.ParseReference(imageRef)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	keychain, err := r.KeychainFactory.KeychainForSecretRef(ctx, registry.SecretRef{
@@ -118,38 +225,32 @@ func (r *DropletReconciler) fetchImageConfig(ctx context.Context, imageRef strin
 		ImagePullSecrets: imagePullSecrets,
 	})
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	img, err := remote.Image(ref, remote.WithAuthFromKeychain(keychain))
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	cfgFile, err := img.ConfigFile()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return &cfgFile.Config, nil
-}
-
-// parse the application configuration from the OCI Image Configuration
-func (r *DropletReconciler) extractImageConfig(ctx context.Context, logger logr.Logger, registry appsv1alpha1.Registry, ns string) (map[string]string, []int32, error) {
-	var imageConfig *v1.Config
-	var err error
-	var exposedPorts []int32
-
-	imageConfig, err = r.fetchImageConfig(ctx, registry.Image, registry.ImagePullSecrets, ns)
+	digest, err := img.Digest()
 	if err != nil {
-		logger.Info(fmt.Sprintf("Error fetching image config: %s\n", err))
-		return nil, exposedPorts, err
+		return nil, "", err
 	}
 
-	exposedPorts, err = extractExposedPorts(imageConfig)
+	return &cfgFile.Config, ref.Context().Digest(digest.String()).Name(), nil
+}
+
+// parse the buildpack process types from the lifecycle metadata label on the OCI Image Configuration
+func extractBuildpackImageConfig(imageConfig *v1.Config) (map[string]string, []int32, error) {
+	exposedPorts, err := extractExposedPorts(imageConfig)
 	if err != nil {
-		logger.Info(fmt.Sprintf("Cannot parse exposed ports from image config.. \n"))
-		return nil, exposedPorts, err
+		return nil, exposedPorts, fmt.Errorf("cannot parse exposed ports from image config: %w", err)
 	}
 
 	// Unmarshall Build Metadata information from Image Config
@@ -168,6 +269,53 @@ func (r *DropletReconciler) extractImageConfig(ctx context.Context, logger logr.
 	return processCommandString, exposedPorts, nil
 }
 
+// derive a single web process from the Entrypoint and Cmd of a Docker image
+func extractDockerImageConfig(imageConfig *v1.Config) (map[string]string, []int32, error) {
+	exposedPorts, err := extractExposedPorts(imageConfig)
+	if err != nil {
+		return nil, exposedPorts, fmt.Errorf("cannot parse exposed ports from image config: %w", err)
+	}
+
+	commandWithArgs := append(append([]string{}, imageConfig.Entrypoint...), imageConfig.Cmd...)
+	processCommandString := map[string]string{
+		"web": strings.Join(commandWithArgs, " "),
+	}
+
+	return processCommandString, exposedPorts, nil
+}
+
+// dockerExecutionMetadataPort and dockerExecutionMetadata mirror the execution metadata Cloud Controller stores for docker droplets
+type dockerExecutionMetadataPort struct {
+	Port     int32  `json:"Port"`
+	Protocol string `json:"Protocol"`
+}
+
+type dockerExecutionMetadataJSON struct {
+	Entrypoint []string                      `json:"entrypoint,omitempty"`
+	Cmd        []string                      `json:"cmd,omitempty"`
+	Workdir    string                        `json:"workdir,omitempty"`
+	User       string                        `json:"user,omitempty"`
+	Ports      []dockerExecutionMetadataPort `json:"ports,omitempty"`
+}
+
+func dockerExecutionMetadata(imageConfig *v1.Config, exposedPorts []int32) (string, error) {
+	metadata := dockerExecutionMetadataJSON{
+		Entrypoint: imageConfig.Entrypoint,
+		Cmd:        imageConfig.Cmd,
+		Workdir:    imageConfig.WorkingDir,
+		User:       imageConfig.User,
+	}
+	for _, port := range exposedPorts {
+		metadata.Ports = append(metadata.Ports, dockerExecutionMetadataPort{Port: port, Protocol: "tcp"})
+	}
+
+	executionMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+	return string(executionMetadata), nil
+}
+
 // Reconstruct command with arguments into a single command string
 func extractFullCommand(process launch.Process) string {
 	commandWithArgs := append([]string{process.Command}, process.Args...)
@@ -176,14 +324,17 @@ func extractFullCommand(process launch.Process) string {
 
 func extractExposedPorts(imageConfig *v1.Config) ([]int32, error) {
 	// Drop the protocol since we only use TCP (the default) and only store the port number
+	// Keys are in the form "8080/tcp", the protocol suffix is optional
 	var ports []int32
 	for port, _ := range imageConfig.ExposedPorts {
-		portInt, err := strconv.Atoi(port)
+		portInt, err := strconv.Atoi(strings.SplitN(port, "/", 2)[0])
 		if err != nil {
 			return []int32{}, err
 		}
 		ports = append(ports, int32(portInt))
 	}
+	// map iteration order is random, keep the droplet spec stable between reconciles
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
 
 	return ports, nil
 }
@@ -0,0 +1,33 @@
+package controllers
+
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reasons of the events the reconcilers record on the objects they act on, so kubectl describe shows what happened
+const (
+	// App
+	ProcessCreatedReason = "ProcessCreated"
+	ProcessUpdatedReason = "ProcessUpdated"
+	PrunedReason         = "Pruned"
+
+	// Build, DropletCreated is recorded on its App too
+	StagingStartedReason   = "StagingStarted"
+	StagingSucceededReason = "StagingSucceeded"
+	StagingFailedReason    = "StagingFailed"
+	DropletCreatedReason   = "DropletCreated"
+
+	// Droplet
+	ImageResolvedReason = "ImageResolved"
+
+	// Process
+	LRPCreatedReason    = "LRPCreated"
+	LRPUpdatedReason    = "LRPUpdated"
+	LRPDeletedReason    = "LRPDeleted"
+	ProcessScaledReason = "ProcessScaled"
+
+	// Job
+	JobCompleteReason = "JobComplete"
+	JobFailedReason   = "JobFailed"
+
+	// App, Package and Droplet finalizers
+	CleanupFailedReason = "CleanupFailed"
+)
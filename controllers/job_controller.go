@@ -0,0 +1,134 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+)
+
+const (
+	// jobPollInterval is how often a PROCESSING Job checks on its operation
+	jobPollInterval = 5 * time.Second
+	// jobTimeout is how long an operation may take before its Job FAILED, like the job timeout of Cloud Controller
+	jobTimeout = 15 * time.Minute
+	// jobRetention is how long finished Jobs are kept for clients to poll
+	jobRetention = 24 * time.Hour
+)
+
+// JobReconciler reconciles a Job object
+type JobReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=jobs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=jobs/finalizers,verbs=update
+
+// Reconcile moves a Job to COMPLETE or FAILED once its operation is done
+// Only deletes are asynchronous so far, the CRD admits no other operation: a Job is COMPLETE once the object it refers
+// to is gone, which is after the finalizers of the object have cleaned up after it
+func (r *JobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	job := &appsv1alpha1.Job{}
+	if err := r.Get(ctx, req.NamespacedName, job); err != nil {
+		logger.Info(fmt.Sprintf("Error fetching job: %s", err))
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	age := time.Since(job.CreationTimestamp.Time)
+	if job.Status.State == appsv1alpha1.JobCompleteState || job.Status.State == appsv1alpha1.JobFailedState {
+		if age < jobRetention {
+			return ctrl.Result{RequeueAfter: jobRetention - age}, nil
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, job))
+	}
+
+	originalJob := job.DeepCopy()
+	result := ctrl.Result{}
+	deleted, err := r.isDeleted(ctx, job)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if deleted {
+		job.Status.State = appsv1alpha1.JobCompleteState
+	} else if age > jobTimeout {
+		job.Status.State = appsv1alpha1.JobFailedState
+		job.Status.Errors = []appsv1alpha1.JobError{{
+			Code:   290006,
+			Title:  "CF-JobTimeout",
+			Detail: fmt.Sprintf("The job execution has timed out, %s %s is still being deleted", job.Spec.ResourceRef.Kind, job.Spec.ResourceRef.Name),
+		}}
+	} else {
+		job.Status.State = appsv1alpha1.JobProcessingState
+		result.RequeueAfter = jobPollInterval
+	}
+
+	if job.Status.State != originalJob.Status.State {
+		if err := r.Status().Patch(ctx, job, client.MergeFrom(originalJob)); err != nil {
+			return ctrl.Result{}, err
+		}
+		logger.Info(fmt.Sprintf("Job %s is %s", job.Spec.Operation, job.Status.State))
+		if job.Status.State == appsv1alpha1.JobCompleteState {
+			r.Recorder.Eventf(job, corev1.EventTypeNormal, JobCompleteReason, "Operation %s completed", job.Spec.Operation)
+		} else if job.Status.State == appsv1alpha1.JobFailedState {
+			r.Recorder.Eventf(job, corev1.EventTypeWarning, JobFailedReason, "Operation %s failed: %s", job.Spec.Operation, job.Status.Errors[0].Detail)
+		}
+	}
+	if result.RequeueAfter == 0 {
+		result.RequeueAfter = jobRetention
+	}
+	return result, nil
+}
+
+// isDeleted is true once the object the Job refers to no longer exists
+func (r *JobReconciler) isDeleted(ctx context.Context, job *appsv1alpha1.Job) (bool, error) {
+	gvk := schema.FromAPIVersionAndKind(job.Spec.ResourceRef.APIVersion, job.Spec.ResourceRef.Kind)
+	obj, err := r.Scheme.New(gvk)
+	if err != nil {
+		return false, err
+	}
+
+	err = r.Get(ctx, types.NamespacedName{Name: job.Spec.ResourceRef.Name, Namespace: job.Namespace}, obj.(client.Object))
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	return false, err
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *JobReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv1alpha1.Job{}).
+		Complete(r)
+}
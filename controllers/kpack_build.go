@@ -0,0 +1,137 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	cfappsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+)
+
+const BuildGUIDLabel = "apps.cloudfoundry.org/buildGuid"
+const BuildReasonAnnotation = "image.kpack.io/reason"
+const StackUpdateBuildReason = "STACK"
+
+var BuildFilterError = errors.New("Received a build event with a non-build runtime.Object")
+
+// buildFilter passes the events of kpack Builds that staged a CF Build once they have completed
+// kpack copies the labels of the Image we created to its Builds, so they carry the GUID of the CF Build
+func buildFilter(e client.Object) bool {
+	ctx := context.Background()
+	logger := log.FromContext(ctx)
+
+	newBuild, ok := e.(*buildv1alpha1.Build)
+	if !ok {
+		logger.WithValues("event", e).Error(BuildFilterError, "ignoring event")
+		return false
+	}
+
+	if _, isGuidPresent := newBuild.ObjectMeta.Labels[BuildGUIDLabel]; !isGuidPresent {
+		logger.WithValues("build", newBuild).V(1).Info("ignoring event: received update event for a non-CF Build resource")
+		return false
+	}
+	buildReason, ok := newBuild.ObjectMeta.Annotations[BuildReasonAnnotation]
+	if !ok {
+		logger.WithValues("build", newBuild).V(1).Info("ignoring event: received update event that was missing the build reason")
+		return false
+	}
+
+	// Ignoring builds triggered by Stack updates for now
+	if buildReason == StackUpdateBuildReason {
+		logger.WithValues("build", newBuild).V(1).Info("ignoring event: build triggered due to an automatic stack update")
+		return false
+	}
+
+	// Wait until the 'Succeeded' condition is in a terminal 'False' or 'True' state
+	if newBuild.Status.GetCondition(corev1alpha1.ConditionSucceeded).IsUnknown() {
+		logger.WithValues("build", newBuild).V(1).Info("ignoring event: build 'Succeeded' condition status is Unknown")
+		return false
+	}
+
+	logger.WithValues("build", newBuild).V(1).Info("event passed ignore filters, continuing with reconciliation")
+	return true
+}
+
+// kpackBuildToCFBuild maps a kpack Build to the CF Build it stages
+func kpackBuildToCFBuild(kpackBuild client.Object) []reconcile.Request {
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{
+			Name:      kpackBuild.GetLabels()[BuildGUIDLabel],
+			Namespace: kpackBuild.GetNamespace(),
+		},
+	}}
+}
+
+// latestKpackBuild returns the newest kpack Build staging the CF Build, or nil if kpack has not started one yet
+// Builds kpack starts on its own after a stack update are left out
+func latestKpackBuild(ctx context.Context, c client.Client, cfBuild *cfappsv1alpha1.Build) (*buildv1alpha1.Build, error) {
+	kpackBuilds := &buildv1alpha1.BuildList{}
+	if err := c.List(ctx, kpackBuilds, client.InNamespace(cfBuild.Namespace), client.MatchingLabels{BuildGUIDLabel: cfBuild.Name}); err != nil {
+		return nil, err
+	}
+
+	var latest *buildv1alpha1.Build
+	for i := range kpackBuilds.Items {
+		kpackBuild := &kpackBuilds.Items[i]
+		if kpackBuild.Annotations[BuildReasonAnnotation] == StackUpdateBuildReason {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&kpackBuild.CreationTimestamp) {
+			latest = kpackBuild
+		}
+	}
+	return latest, nil
+}
+
+// kpackBuildFailureMessage describes why a kpack Build failed, from the step that failed if there is one
+func kpackBuildFailureMessage(kpackBuild *buildv1alpha1.Build) string {
+	failedContainerState := findAnyFailedContainerState(kpackBuild.Status.StepStates)
+	if failedContainerState != nil {
+		return fmt.Sprintf(
+			"Kpack build failed during container execution: Step failure reason: '%s', message: '%s'.",
+			failedContainerState.Terminated.Reason,
+			failedContainerState.Terminated.Message,
+		)
+	}
+
+	condition := kpackBuild.Status.GetCondition(corev1alpha1.ConditionSucceeded)
+	return fmt.Sprintf(
+		"Kpack build unsuccessful: Build failure reason: '%s', message: '%s'.",
+		condition.Reason,
+		condition.Message,
+	)
+}
+
+// returns true if any container has terminated with a non-zero exit code
+func findAnyFailedContainerState(containerStates []corev1.ContainerState) *corev1.ContainerState {
+	for _, container := range containerStates {
+		if container.Terminated != nil && container.Terminated.ExitCode != 0 {
+			return &container
+		}
+	}
+	return nil
+}
@@ -18,24 +18,38 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/pivotal/kpack/pkg/registry"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/settings"
+	"cloudfoundry.org/cf-crd-explorations/tracing"
 )
 
+// PackageCleanupFinalizer holds a deleted Package until its docker secret and uploaded bits are deleted
+const PackageCleanupFinalizer = "apps.cloudfoundry.org/package-cleanup"
+
 // PackageReconciler reconciles a Package object
 type PackageReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme          *runtime.Scheme
+	Recorder        record.EventRecorder
+	KeychainFactory registry.KeychainFactory
 }
 
 //+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=packages,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=packages/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=packages/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -47,27 +61,61 @@ type PackageReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.8.3/pkg/reconcile
 func (r *PackageReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	logger := log.FromContext(ctx)
+
+	pk := &appsv1alpha1.Package{}
+	if err := r.Get(ctx, req.NamespacedName, pk); err != nil {
+		logger.Info(fmt.Sprintf("Error fetching package: %s", err))
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	ctx, span := tracing.StartReconcile(ctx, "PackageReconciler", pk, pk)
+	defer span.End()
 
-	// your logic here
+	if !pk.DeletionTimestamp.IsZero() {
+		err := r.cleanupPackage(ctx, pk)
+		if err != nil {
+			r.Recorder.Event(pk, corev1.EventTypeWarning, CleanupFailedReason, err.Error())
+		}
+		return ctrl.Result{}, err
+	}
+	if !controllerutil.ContainsFinalizer(pk, PackageCleanupFinalizer) {
+		controllerutil.AddFinalizer(pk, PackageCleanupFinalizer)
+		if err := r.Update(ctx, pk); err != nil {
+			logger.Info(fmt.Sprintf("Error adding finalizer to package: %s", err))
+			return ctrl.Result{}, err
+		}
+	}
 
 	return ctrl.Result{}, nil
 }
 
-var (
-	metadataName = "metadata.name"
-)
+// cleanupPackage deletes the secret created for a docker Package and the image its bits were uploaded to
+func (r *PackageReconciler) cleanupPackage(ctx context.Context, pk *appsv1alpha1.Package) error {
+	if !controllerutil.ContainsFinalizer(pk, PackageCleanupFinalizer) {
+		return nil
+	}
 
-// SetupWithManager sets up the controller with the Manager.
-func (r *PackageReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	// Set up field indexer for for metadata.name
-	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &appsv1alpha1.Package{}, metadataName, func(rawObj client.Object) []string {
-		pkg := rawObj.(*appsv1alpha1.Package)
-		return []string{pkg.ObjectMeta.Name}
-	}); err != nil {
-		return err
+	for _, secretRef := range pk.Spec.Source.Registry.ImagePullSecrets {
+		// Bits packages refer to the registry secret of the shim, which is shared by all of them
+		if secretRef.Name == settings.GlobalSettings.RegistrySecret {
+			continue
+		}
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretRef.Name, Namespace: pk.Namespace}}
+		if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("error deleting package secret: %w", err)
+		}
+	}
+
+	if err := deletePlatformImage(ctx, r.KeychainFactory, pk.Namespace, pk.Spec.Source.Registry.Image); err != nil {
+		return fmt.Errorf("error deleting package image: %w", err)
 	}
 
+	controllerutil.RemoveFinalizer(pk, PackageCleanupFinalizer)
+	return r.Update(ctx, pk)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PackageReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&appsv1alpha1.Package{}).
 		Complete(r)
@@ -18,11 +18,15 @@ package controllers
 
 import (
 	"cloudfoundry.org/cf-crd-explorations/cfshim/handlers"
+	"cloudfoundry.org/cf-crd-explorations/indexes"
+	"cloudfoundry.org/cf-crd-explorations/metrics"
+	"cloudfoundry.org/cf-crd-explorations/tracing"
 	"context"
 	"fmt"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -41,7 +45,8 @@ import (
 // ProcessReconciler reconciles a Process object
 type ProcessReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=processes,verbs=get;list;watch;create;update;patch;delete
@@ -68,6 +73,7 @@ func (r *ProcessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	if err := r.Get(ctx, req.NamespacedName, process); err != nil {
 		if apierrors.IsNotFound(err) {
 			logger.Info("Process no longer exists")
+			metrics.DeleteProcess(req.Namespace, req.Name)
 		}
 		logger.Info(fmt.Sprintf("Error fetching process: %s", err))
 		return ctrl.Result{}, client.IgnoreNotFound(err)
@@ -78,6 +84,9 @@ func (r *ProcessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		logger.Info(fmt.Sprintf("Error fetching app: %s", err))
 		return ctrl.Result{}, err
 	}
+	// Processes are changed by the AppReconciler, so their reconciles join the trace of the last traced change to the App
+	ctx, span := tracing.StartReconcile(ctx, "ProcessReconciler", process, app)
+	defer span.End()
 
 	// fetch the Droplet to get the imageRef
 	droplet := new(cfappsv1alpha1.Droplet)
@@ -95,6 +104,13 @@ func (r *ProcessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
+	// Eirini creates its own pull secret for the LRP from plain credentials, so pass along the ones from the Droplet's dockerconfigjson secret
+	privateRegistry, err := r.privateRegistryForDroplet(ctx, droplet)
+	if err != nil {
+		logger.Info(fmt.Sprintf("Error fetching droplet image pull secret: %s", err))
+		return ctrl.Result{}, err
+	}
+
 	// fetch the LRP if it exists
 	existingLRP := new(eiriniv1.LRP)
 	lrpExists := true
@@ -103,8 +119,14 @@ func (r *ProcessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		logger.Info(fmt.Sprintf("Could not fetch LRP: %s", err))
 		lrpExists = false
 	}
+	observeInstances(process, app, existingLRP, lrpExists)
 
 	if app.Spec.DesiredState == cfappsv1alpha1.StartedState {
+		// Only deploy pinned digests, the Droplet watch requeues this Process once the DropletReconciler resolves it
+		if droplet.Status.ResolvedImage == "" {
+			logger.Info(fmt.Sprintf("Waiting for droplet %s image digest to be resolved", droplet.Name))
+			return ctrl.Result{}, nil
+		}
 
 		// build the Deployment that we want
 		desiredEiriniLRP := eiriniv1.LRP{
@@ -126,23 +148,19 @@ func (r *ProcessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 				},
 			},
 			Spec: eiriniv1.LRPSpec{
-				GUID:        process.Name,
-				Version:     process.ResourceVersion, // TODO: Do we care about this?
-				ProcessType: process.Spec.ProcessType,
-				AppName:     app.Spec.Name,
-				AppGUID:     app.Name,
-				OrgName:     "TBD",
-				OrgGUID:     "TBD",
-				SpaceName:   "TBD",
-				SpaceGUID:   "TBD",
-				Image:       droplet.Spec.Registry.Image,
-				Command:     commandForProcess(process, app),
-				Sidecars:    nil,
-				// TODO: Used for Docker images?
-				//PrivateRegistry: &eiriniv1.PrivateRegistry{
-				//	Username: "",
-				//	Password: "",
-				//},
+				GUID:            process.Name,
+				Version:         process.ResourceVersion, // TODO: Do we care about this?
+				ProcessType:     process.Spec.ProcessType,
+				AppName:         app.Spec.Name,
+				AppGUID:         app.Name,
+				OrgName:         "TBD",
+				OrgGUID:         "TBD",
+				SpaceName:       "TBD",
+				SpaceGUID:       "TBD",
+				Image:           droplet.Status.ResolvedImage,
+				Command:         commandForProcess(process, app),
+				Sidecars:        nil,
+				PrivateRegistry: privateRegistry,
 				// TODO: Can Eirini LRP be updated to take a secret name?
 				Env: secretDataToEnvMap(appEnvSecret.Data),
 				Health: eiriniv1.Healthcheck{
@@ -174,6 +192,13 @@ func (r *ProcessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 
 		logger.Info(fmt.Sprintf("Successfully Created/Updated LRP: %s", result))
+		if result == controllerutil.OperationResultCreated {
+			r.Recorder.Eventf(process, corev1.EventTypeNormal, LRPCreatedReason, "Created LRP with %d instances", process.Spec.Instances)
+		} else if result == controllerutil.OperationResultUpdated && lrpExists && existingLRP.Spec.Instances != process.Spec.Instances {
+			r.Recorder.Eventf(process, corev1.EventTypeNormal, ProcessScaledReason, "Scaled from %d to %d instances", existingLRP.Spec.Instances, process.Spec.Instances)
+		} else if result == controllerutil.OperationResultUpdated {
+			r.Recorder.Event(process, corev1.EventTypeNormal, LRPUpdatedReason, "Updated LRP")
+		}
 
 	} else if app.Spec.DesiredState == cfappsv1alpha1.StoppedState {
 		// delete the LRP if it exists and desired state is "STOPPED"
@@ -184,6 +209,7 @@ func (r *ProcessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 				return ctrl.Result{}, err
 			}
 			logger.Info(fmt.Sprintf("Successfully Deleted LRP: %s", process.Name))
+			r.Recorder.Event(process, corev1.EventTypeNormal, LRPDeletedReason, "Deleted LRP, the app is STOPPED")
 		} else {
 			logger.Info("Nothing to do: app desired state is \"STOPPED\"")
 		}
@@ -192,6 +218,41 @@ func (r *ProcessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	return ctrl.Result{}, nil
 }
 
+// observeInstances reports the instances the Process should run against the ready instances of its LRP, the LRP watch
+// reconciles the Process again when those change
+func observeInstances(process *cfappsv1alpha1.Process, app *cfappsv1alpha1.App, lrp *eiriniv1.LRP, lrpExists bool) {
+	desired, running := 0, 0
+	if app.Spec.DesiredState == cfappsv1alpha1.StartedState {
+		desired = process.Spec.Instances
+	}
+	if lrpExists {
+		running = int(lrp.Status.Replicas)
+	}
+	metrics.ProcessDesiredInstances.WithLabelValues(process.Namespace, process.Name).Set(float64(desired))
+	metrics.ProcessRunningInstances.WithLabelValues(process.Namespace, process.Name).Set(float64(running))
+}
+
+// privateRegistryForDroplet returns the registry credentials for a docker Droplet image, or nil if the image is public
+func (r *ProcessReconciler) privateRegistryForDroplet(ctx context.Context, droplet *cfappsv1alpha1.Droplet) (*eiriniv1.PrivateRegistry, error) {
+	if droplet.Spec.Type != cfappsv1alpha1.DockerLifecycle {
+		return nil, nil
+	}
+
+	for _, pullSecret := range droplet.Spec.Registry.ImagePullSecrets {
+		secret := new(corev1.Secret)
+		if err := r.Get(ctx, types.NamespacedName{Name: pullSecret.Name, Namespace: droplet.Namespace}, secret); err != nil {
+			return nil, err
+		}
+		if username, password, ok := handlers.DockerCredentialsFromSecret(secret, droplet.Spec.Registry.Image); ok {
+			return &eiriniv1.PrivateRegistry{
+				Username: username,
+				Password: password,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
 func eiriniLRPMutateFunction(actualLRP, desiredLRP *eiriniv1.LRP) controllerutil.MutateFn {
 	return func() error {
 		actualLRP.ObjectMeta.Labels = desiredLRP.ObjectMeta.Labels
@@ -224,9 +285,11 @@ func secretDataToEnvMap(secretData map[string][]byte) map[string]string {
 func (r *ProcessReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	err := ctrl.NewControllerManagedBy(mgr).
 		For(&cfappsv1alpha1.Process{}).
+		// The LRP is owned by the Process without being its controller, its status has the running instances
+		Watches(&source.Kind{Type: &eiriniv1.LRP{}}, &handler.EnqueueRequestForOwner{OwnerType: &cfappsv1alpha1.Process{}}).
 		Watches(&source.Kind{Type: &cfappsv1alpha1.App{}}, handler.EnqueueRequestsFromMapFunc(func(app client.Object) []reconcile.Request {
 			processList := &cfappsv1alpha1.ProcessList{}
-			_ = mgr.GetClient().List(context.Background(), processList, client.InNamespace(app.GetNamespace()), client.MatchingLabels{handlers.LabelAppGUID: app.GetName()})
+			_ = mgr.GetClient().List(context.Background(), processList, client.InNamespace(app.GetNamespace()), client.MatchingFields{indexes.AppGUIDLabel: app.GetName()})
 			var requests []reconcile.Request
 
 			for _, process := range processList.Items {
@@ -241,7 +304,7 @@ func (r *ProcessReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		})).
 		Watches(&source.Kind{Type: &cfappsv1alpha1.Droplet{}}, handler.EnqueueRequestsFromMapFunc(func(droplet client.Object) []reconcile.Request {
 			processList := &cfappsv1alpha1.ProcessList{}
-			_ = mgr.GetClient().List(context.Background(), processList, client.InNamespace(droplet.GetNamespace()), client.MatchingLabels{handlers.LabelAppGUID: droplet.GetLabels()[handlers.LabelAppGUID]})
+			_ = mgr.GetClient().List(context.Background(), processList, client.InNamespace(droplet.GetNamespace()), client.MatchingFields{indexes.AppGUIDLabel: droplet.GetLabels()[handlers.LabelAppGUID]})
 			var requests []reconcile.Request
 
 			for _, process := range processList.Items {
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/pivotal/kpack/pkg/registry"
+	corev1 "k8s.io/api/core/v1"
+
+	"cloudfoundry.org/cf-crd-explorations/settings"
+)
+
+// isPlatformImage is true for images the shim or kpack pushed, under the package or build registry
+// Docker packages and droplets refer to images of the user, those are never deleted
+func isPlatformImage(image string) bool {
+	for _, base := range []string{settings.GlobalSettings.PackageRegistryBase, settings.GlobalSettings.RegistryTagBase} {
+		if base != "" && strings.HasPrefix(image, base+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// platformRegistrySecretRef returns the credentials a platform image was pushed with: the registry secret of the shim
+// for package bits and the kpack service account of the namespace for built images
+func platformRegistrySecretRef(namespace string, image string) registry.SecretRef {
+	if strings.HasPrefix(image, settings.GlobalSettings.PackageRegistryBase+"/") {
+		return registry.SecretRef{
+			Namespace:        "default",
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: settings.GlobalSettings.RegistrySecret}},
+		}
+	}
+	return registry.SecretRef{
+		Namespace:      namespace,
+		ServiceAccount: "kpack-service-account",
+	}
+}
+
+// deletePlatformImage deletes the manifest a platform image tag or digest points to, other images are left alone
+// Registries only delete manifests by digest, so a tag is resolved first. An image that is already gone is not an error
+func deletePlatformImage(ctx context.Context, keychainFactory registry.KeychainFactory, namespace string, image string) error {
+	if !isPlatformImage(image) {
+		return nil
+	}
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return err
+	}
+
+	keychain, err := keychainFactory.KeychainForSecretRef(ctx, platformRegistrySecretRef(namespace, image))
+	if err != nil {
+		return err
+	}
+	options := []remote.Option{remote.WithAuthFromKeychain(keychain), remote.WithContext(ctx)}
+
+	descriptor, err := remote.Head(ref, options...)
+	if isRegistryNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = remote.Delete(ref.Context().Digest(descriptor.Digest.String()), options...)
+	if isRegistryNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func isRegistryNotFound(err error) bool {
+	var transportErr *transport.Error
+	return errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/audit"
+	"cloudfoundry.org/cf-crd-explorations/indexes"
+)
+
+// RetentionReconciler prunes the Droplets, Packages and Builds of an App that are older than the newest ones it keeps,
+// like the droplet and package retention of Cloud Controller. The finalizers of Droplets and Packages delete their images
+type RetentionReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// MaxRetainedDroplets and MaxRetainedPackages are how many of the newest of each are kept per App, 0 keeps all of them
+	MaxRetainedDroplets int64
+	MaxRetainedPackages int64
+}
+
+//+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=apps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=droplets,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=packages,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=builds,verbs=get;list;watch;delete
+
+// Reconcile keeps the current Droplet of the App and its newest Droplets and Packages, and deletes the rest
+// A Build is deleted once neither its Package nor its Droplet is kept, its kpack Image goes with it
+func (r *RetentionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	app := &appsv1alpha1.App{}
+	if err := r.Get(ctx, req.NamespacedName, app); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	// Everything of a deleted App goes, see AppReconciler
+	if !app.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	ofApp := []client.ListOption{client.InNamespace(app.Namespace), client.MatchingFields{indexes.AppRef: app.Name}}
+	droplets := &appsv1alpha1.DropletList{}
+	if err := r.List(ctx, droplets, ofApp...); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error fetching droplets: %w", err)
+	}
+	packages := &appsv1alpha1.PackageList{}
+	if err := r.List(ctx, packages, ofApp...); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error fetching packages: %w", err)
+	}
+	builds := &appsv1alpha1.BuildList{}
+	if err := r.List(ctx, builds, ofApp...); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error fetching builds: %w", err)
+	}
+
+	var dropletObjects, packageObjects []client.Object
+	for i := range droplets.Items {
+		dropletObjects = append(dropletObjects, &droplets.Items[i])
+	}
+	for i := range packages.Items {
+		packageObjects = append(packageObjects, &packages.Items[i])
+	}
+
+	keptDroplets, prunedDroplets := retain(dropletObjects, r.MaxRetainedDroplets, app.Spec.CurrentDropletRef.Name)
+	keptPackages, prunedPackages := retain(packageObjects, r.MaxRetainedPackages, "")
+
+	var prunedBuilds []client.Object
+	for i := range builds.Items {
+		build := &builds.Items[i]
+		if !keptPackages[build.Spec.PackageRef.Name] && !keptDroplets[build.Status.DropletReference.Name] {
+			prunedBuilds = append(prunedBuilds, build)
+		}
+	}
+
+	for _, pruned := range [][]client.Object{prunedDroplets, prunedPackages, prunedBuilds} {
+		for _, obj := range pruned {
+			if !obj.GetDeletionTimestamp().IsZero() {
+				continue
+			}
+			if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return ctrl.Result{}, fmt.Errorf("error deleting %s: %w", obj.GetName(), err)
+			}
+			logger.Info(fmt.Sprintf("Pruned %T %s", obj, obj.GetName()))
+			r.Recorder.Eventf(app, corev1.EventTypeNormal, PrunedReason, "Pruned %s %s", pruneKind(obj), obj.GetName())
+			r.recordPruned(ctx, app, obj)
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// retain splits objects into the names of the newest max of them, which always include current, and the others
+// A max of 0 or less keeps everything
+func retain(objects []client.Object, max int64, current string) (map[string]bool, []client.Object) {
+	kept := map[string]bool{}
+	if max <= 0 {
+		for _, obj := range objects {
+			kept[obj.GetName()] = true
+		}
+		return kept, nil
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		ti, tj := objects[i].GetCreationTimestamp(), objects[j].GetCreationTimestamp()
+		if ti.Equal(&tj) {
+			return objects[i].GetName() < objects[j].GetName()
+		}
+		return tj.Before(&ti)
+	})
+
+	if current != "" {
+		kept[current] = true
+	}
+	var pruned []client.Object
+	for _, obj := range objects {
+		if kept[obj.GetName()] {
+			continue
+		}
+		if int64(len(kept)) < max {
+			kept[obj.GetName()] = true
+		} else {
+			pruned = append(pruned, obj)
+		}
+	}
+	return kept, pruned
+}
+
+func pruneKind(obj client.Object) string {
+	switch obj.(type) {
+	case *appsv1alpha1.Droplet:
+		return "droplet"
+	case *appsv1alpha1.Package:
+		return "package"
+	default:
+		return "build"
+	}
+}
+
+// recordPruned records the audit event of a pruned Droplet or Package, Builds have none
+// Failing to record it is only logged, the object is gone already
+func (r *RetentionReconciler) recordPruned(ctx context.Context, app *appsv1alpha1.App, obj client.Object) {
+	var eventType, dataKey string
+	switch obj.(type) {
+	case *appsv1alpha1.Droplet:
+		eventType, dataKey = audit.AppDropletDelete, "droplet_guid"
+	case *appsv1alpha1.Package:
+		eventType, dataKey = audit.AppPackageDelete, "package_guid"
+	default:
+		return
+	}
+	err := audit.Record(ctx, r.Client, app.Namespace, eventType, audit.SystemActor, audit.AppTarget(app), map[string]string{dataKey: obj.GetName()})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error recording audit event", "type", eventType)
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RetentionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	enqueueApp := handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		var appName string
+		switch o := obj.(type) {
+		case *appsv1alpha1.Droplet:
+			appName = o.Spec.AppRef.Name
+		case *appsv1alpha1.Package:
+			appName = o.Spec.AppRef.Name
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: appName, Namespace: obj.GetNamespace()}}}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("retention").
+		For(&appsv1alpha1.App{}).
+		Watches(&source.Kind{Type: &appsv1alpha1.Droplet{}}, enqueueApp).
+		Watches(&source.Kind{Type: &appsv1alpha1.Package{}}, enqueueApp).
+		Complete(r)
+}
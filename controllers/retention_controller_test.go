@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+)
+
+func TestRetain(t *testing.T) {
+	created := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	newDroplets := func() []client.Object {
+		var droplets []client.Object
+		// droplet-n is created n minutes in, so droplet-1 is the oldest and droplet-4 the newest
+		for _, n := range []int{3, 1, 4, 2} {
+			droplets = append(droplets, &appsv1alpha1.Droplet{ObjectMeta: metav1.ObjectMeta{
+				Name:              fmt.Sprintf("droplet-%d", n),
+				CreationTimestamp: metav1.NewTime(created.Add(time.Duration(n) * time.Minute)),
+			}})
+		}
+		return droplets
+	}
+	names := func(kept map[string]bool, pruned []client.Object) ([]string, []string) {
+		var keptNames, prunedNames []string
+		for name := range kept {
+			keptNames = append(keptNames, name)
+		}
+		for _, obj := range pruned {
+			prunedNames = append(prunedNames, obj.GetName())
+		}
+		sort.Strings(keptNames)
+		return keptNames, prunedNames
+	}
+
+	for _, tc := range []struct {
+		description    string
+		max            int64
+		current        string
+		expectedKept   []string
+		expectedPruned []string
+	}{
+		{"0 keeps everything", 0, "", []string{"droplet-1", "droplet-2", "droplet-3", "droplet-4"}, nil},
+		{"the newest are kept", 2, "", []string{"droplet-3", "droplet-4"}, []string{"droplet-2", "droplet-1"}},
+		{"the current one counts towards max", 2, "droplet-1", []string{"droplet-1", "droplet-4"}, []string{"droplet-3", "droplet-2"}},
+		{"the current one is kept above max", 1, "droplet-4", []string{"droplet-4"}, []string{"droplet-3", "droplet-2", "droplet-1"}},
+		{"max above the count keeps everything", 5, "", []string{"droplet-1", "droplet-2", "droplet-3", "droplet-4"}, nil},
+	} {
+		kept, pruned := names(retain(newDroplets(), tc.max, tc.current))
+		if !reflect.DeepEqual(kept, tc.expectedKept) || !reflect.DeepEqual(pruned, tc.expectedPruned) {
+			t.Errorf("%s: expected to keep %v and prune %v, kept %v and pruned %v", tc.description, tc.expectedKept, tc.expectedPruned, kept, pruned)
+		}
+	}
+}
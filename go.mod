@@ -6,14 +6,26 @@ require (
 	code.cloudfoundry.org/eirini v0.0.0-20210609140938-f9ca28490ea1
 	github.com/buildpacks/lifecycle v0.11.3
 	github.com/buildpacks/pack v0.19.0
+	github.com/coreos/go-oidc v2.1.0+incompatible
 	github.com/go-logr/logr v0.4.0
 	github.com/google/go-containerregistry v0.5.1
+	github.com/google/gofuzz v1.2.0
 	github.com/google/uuid v1.2.0
 	github.com/gorilla/mux v1.8.0
 	github.com/onsi/ginkgo v1.16.4
 	github.com/onsi/gomega v1.13.0
 	github.com/pivotal/kpack v0.3.1
+	github.com/prometheus/client_golang v1.11.0
+	github.com/prometheus/client_model v0.2.0
+	go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp v0.27.0
+	go.opentelemetry.io/otel v1.2.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.2.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.2.0
+	go.opentelemetry.io/otel/sdk v1.2.0
+	go.opentelemetry.io/otel/trace v1.2.0
+	gopkg.in/square/go-jose.v2 v2.5.1
 	k8s.io/api v0.21.1
+	k8s.io/apiextensions-apiserver v0.21.1
 	k8s.io/apimachinery v0.21.1
 	k8s.io/client-go v0.21.1
 	sigs.k8s.io/controller-runtime v0.9.0
@@ -0,0 +1,111 @@
+package indexes
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+)
+
+// Field indexes on the manager cache, so lookups by GUID, name or App do not have to list every object in the cluster
+// Query them with client.MatchingFields, optionally together with client.InNamespace
+// See: https://book.kubebuilder.io/cronjob-tutorial/controller-implementation.html#setup
+const (
+	// Name indexes every kind by metadata.name, the CF GUID, across namespaces, which also finds the namespace of a GUID
+	Name = "metadata.name"
+	// AppName indexes Apps by spec.name, the CF App name
+	AppName = "spec.name"
+	// AppRef indexes Packages, Builds, Droplets and Processes by the GUID of the App they belong to
+	AppRef = "spec.appRef.name"
+	// BuildPackageRef indexes Builds by the GUID of the Package they stage
+	BuildPackageRef = "spec.packageRef.name"
+	// AppGUIDLabel indexes Processes by their apps.cloudfoundry.org/appGuid label
+	AppGUIDLabel = "metadata.labels.appGuid"
+	// AuditEventTarget indexes AuditEvents by the GUID of their target
+	AuditEventTarget = "spec.target.guid"
+	// DropletResolvedImage indexes Droplets by the image digest they are pinned to, Droplets that are not pinned yet are left out
+	DropletResolvedImage = "status.resolvedImage"
+
+	appGUIDLabelKey = "apps.cloudfoundry.org/appGuid"
+)
+
+// Setup registers all the field indexes, it must be called before the manager is started
+func Setup(ctx context.Context, indexer client.FieldIndexer) error {
+	for _, obj := range []client.Object{
+		&appsv1alpha1.App{},
+		&appsv1alpha1.Package{},
+		&appsv1alpha1.Build{},
+		&appsv1alpha1.Droplet{},
+		&appsv1alpha1.Process{},
+		&appsv1alpha1.Job{},
+		&appsv1alpha1.AuditEvent{},
+	} {
+		if err := indexer.IndexField(ctx, obj, Name, indexName); err != nil {
+			return err
+		}
+	}
+
+	if err := indexer.IndexField(ctx, &appsv1alpha1.App{}, AppName, func(obj client.Object) []string {
+		return []string{obj.(*appsv1alpha1.App).Spec.Name}
+	}); err != nil {
+		return err
+	}
+
+	if err := indexer.IndexField(ctx, &appsv1alpha1.Package{}, AppRef, func(obj client.Object) []string {
+		return []string{obj.(*appsv1alpha1.Package).Spec.AppRef.Name}
+	}); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &appsv1alpha1.Build{}, AppRef, func(obj client.Object) []string {
+		return []string{obj.(*appsv1alpha1.Build).Spec.AppRef.Name}
+	}); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &appsv1alpha1.Droplet{}, AppRef, func(obj client.Object) []string {
+		return []string{obj.(*appsv1alpha1.Droplet).Spec.AppRef.Name}
+	}); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &appsv1alpha1.Process{}, AppRef, func(obj client.Object) []string {
+		return []string{obj.(*appsv1alpha1.Process).Spec.AppRef.Name}
+	}); err != nil {
+		return err
+	}
+
+	if err := indexer.IndexField(ctx, &appsv1alpha1.Build{}, BuildPackageRef, func(obj client.Object) []string {
+		return []string{obj.(*appsv1alpha1.Build).Spec.PackageRef.Name}
+	}); err != nil {
+		return err
+	}
+
+	if err := indexer.IndexField(ctx, &appsv1alpha1.Droplet{}, DropletResolvedImage, func(obj client.Object) []string {
+		if image := obj.(*appsv1alpha1.Droplet).Status.ResolvedImage; image != "" {
+			return []string{image}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := indexer.IndexField(ctx, &appsv1alpha1.AuditEvent{}, AuditEventTarget, func(obj client.Object) []string {
+		return []string{obj.(*appsv1alpha1.AuditEvent).Spec.Target.GUID}
+	}); err != nil {
+		return err
+	}
+
+	return indexer.IndexField(ctx, &appsv1alpha1.Process{}, AppGUIDLabel, indexAppGUIDLabel)
+}
+
+func indexName(obj client.Object) []string {
+	return []string{obj.GetName()}
+}
+
+// indexAppGUIDLabel leaves objects without the label out of the index
+func indexAppGUIDLabel(obj client.Object) []string {
+	appGUID, ok := obj.GetLabels()[appGUIDLabelKey]
+	if !ok {
+		return nil
+	}
+	return []string{appGUID}
+}
@@ -0,0 +1,79 @@
+package indexes_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/indexes"
+)
+
+// recordingIndexer keeps the extract funcs by kind and field, like the manager cache it rejects duplicates
+type recordingIndexer map[string]client.IndexerFunc
+
+func (r recordingIndexer) IndexField(_ context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	key := fmt.Sprintf("%T/%s", obj, field)
+	if _, ok := r[key]; ok {
+		return fmt.Errorf("indexer conflict: %s", key)
+	}
+	r[key] = extractValue
+	return nil
+}
+
+func TestSetup(t *testing.T) {
+	indexer := recordingIndexer{}
+	if err := indexes.Setup(context.Background(), indexer); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &appsv1alpha1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-app-guid"},
+		Spec:       appsv1alpha1.AppSpec{Name: "my-app"},
+	}
+	build := &appsv1alpha1.Build{
+		Spec: appsv1alpha1.BuildSpec{PackageRef: appsv1alpha1.PackageReference{Name: "my-package-guid"}},
+	}
+	droplet := &appsv1alpha1.Droplet{
+		Status: appsv1alpha1.DropletStatus{ResolvedImage: "registry.example.com/my-app-guid@sha256:abc"},
+	}
+	process := &appsv1alpha1.Process{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-process-guid", Labels: map[string]string{"apps.cloudfoundry.org/appGuid": "my-app-guid"}},
+		Spec:       appsv1alpha1.ProcessSpec{AppRef: appsv1alpha1.ApplicationReference{Name: "my-app-guid"}},
+	}
+
+	for _, tc := range []struct {
+		key      string
+		obj      client.Object
+		expected []string
+	}{
+		{"*v1alpha1.App/" + indexes.Name, app, []string{"my-app-guid"}},
+		{"*v1alpha1.App/" + indexes.AppName, app, []string{"my-app"}},
+		{"*v1alpha1.Build/" + indexes.BuildPackageRef, build, []string{"my-package-guid"}},
+		{"*v1alpha1.Droplet/" + indexes.DropletResolvedImage, droplet, []string{"registry.example.com/my-app-guid@sha256:abc"}},
+		{"*v1alpha1.Droplet/" + indexes.DropletResolvedImage, &appsv1alpha1.Droplet{}, nil},
+		{"*v1alpha1.Process/" + indexes.AppRef, process, []string{"my-app-guid"}},
+		{"*v1alpha1.Process/" + indexes.AppGUIDLabel, process, []string{"my-app-guid"}},
+		{"*v1alpha1.Process/" + indexes.AppGUIDLabel, &appsv1alpha1.Process{}, nil},
+	} {
+		extract, ok := indexer[tc.key]
+		if !ok {
+			t.Errorf("expected an index %s", tc.key)
+			continue
+		}
+		if values := extract(tc.obj); !reflect.DeepEqual(values, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.key, tc.expected, values)
+		}
+	}
+	for _, kind := range []string{"Package", "Build", "Droplet"} {
+		for _, field := range []string{indexes.Name, indexes.AppRef} {
+			if _, ok := indexer["*v1alpha1."+kind+"/"+field]; !ok {
+				t.Errorf("expected %s to be indexed by %s", kind, field)
+			}
+		}
+	}
+}
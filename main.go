@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"net/http"
@@ -36,13 +37,18 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	appsv1beta1 "cloudfoundry.org/cf-crd-explorations/api/v1beta1"
+	"cloudfoundry.org/cf-crd-explorations/cfshim/auth"
 	"cloudfoundry.org/cf-crd-explorations/cfshim/handlers"
 	eiriniv1 "code.cloudfoundry.org/eirini/pkg/apis/eirini/v1"
 
 	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
 
 	"cloudfoundry.org/cf-crd-explorations/controllers"
+	"cloudfoundry.org/cf-crd-explorations/indexes"
+	"cloudfoundry.org/cf-crd-explorations/metrics"
 	"cloudfoundry.org/cf-crd-explorations/settings"
+	"cloudfoundry.org/cf-crd-explorations/tracing"
 	"github.com/gorilla/mux"
 	//+kubebuilder:scaffold:imports
 )
@@ -56,6 +62,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(appsv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(appsv1beta1.AddToScheme(scheme))
 	utilruntime.Must(buildv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(eiriniv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
@@ -69,6 +76,12 @@ func main() {
 	}
 	settings.GlobalSettings = loadedSettings
 
+	loadedDefaults, err := settings.LoadDefaults()
+	if err != nil {
+		panic(err.Error())
+	}
+	settings.GlobalDefaults = loadedDefaults
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
@@ -98,6 +111,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The controllers and the shim look objects up by these indexes instead of listing everything
+	if err := indexes.Setup(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		setupLog.Error(err, "unable to set up field indexes")
+		os.Exit(1)
+	}
+
+	// Spans of the shim and the reconcilers are exported as configured by OTEL_TRACES_EXPORTER, see package tracing
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	client := kubernetes.NewForConfigOrDie(mgr.GetConfig())
 	keychainFactory, err := k8sdockercreds.NewSecretKeychainFactory(client)
 	if err != nil {
@@ -105,29 +131,35 @@ func main() {
 	}
 
 	if err = (&controllers.AppReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Recorder:        mgr.GetEventRecorderFor("app-controller"),
+		KeychainFactory: keychainFactory,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "App")
 		os.Exit(1)
 	}
 	if err = (&controllers.ProcessReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("process-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Process")
 		os.Exit(1)
 	}
 	if err = (&controllers.PackageReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Recorder:        mgr.GetEventRecorderFor("package-controller"),
+		KeychainFactory: keychainFactory,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Package")
 		os.Exit(1)
 	}
 	if err = (&controllers.BuildReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("build-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Build")
 		os.Exit(1)
@@ -135,6 +167,7 @@ func main() {
 	if err = (&controllers.DropletReconciler{
 		Client:          mgr.GetClient(),
 		Scheme:          mgr.GetScheme(),
+		Recorder:        mgr.GetEventRecorderFor("droplet-controller"),
 		KeychainFactory: keychainFactory,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Droplet")
@@ -147,11 +180,22 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "AppManifest")
 		os.Exit(1)
 	}
-	if err = (&controllers.CFKpackBuildReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+	if err = (&controllers.JobReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("job-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Job")
+		os.Exit(1)
+	}
+	if err = (&controllers.RetentionReconciler{
+		Client:              mgr.GetClient(),
+		Scheme:              mgr.GetScheme(),
+		Recorder:            mgr.GetEventRecorderFor("retention-controller"),
+		MaxRetainedDroplets: settings.GlobalSettings.MaxRetainedDroplets,
+		MaxRetainedPackages: settings.GlobalSettings.MaxRetainedPackages,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "CFKpackBuildReconciler")
+		setupLog.Error(err, "unable to create controller", "controller", "Retention")
 		os.Exit(1)
 	}
 	//+kubebuilder:scaffold:builder
@@ -165,30 +209,82 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The shim acts as the caller of each request, see package auth. Without authentication it acts as the manager
+	var authenticator auth.Authenticator
+	switch settings.GlobalSettings.Authentication {
+	case settings.JWTAuthentication:
+		authenticator, err = auth.NewJWTAuthenticator(context.Background(), auth.JWTConfig{
+			JWKSURL:        settings.GlobalSettings.JWTJWKSURL,
+			Issuer:         settings.GlobalSettings.JWTIssuer,
+			Audience:       settings.GlobalSettings.JWTAudience,
+			UsernameClaim:  settings.GlobalSettings.JWTUsernameClaim,
+			UsernamePrefix: settings.GlobalSettings.JWTUsernamePrefix,
+			GroupsClaim:    settings.GlobalSettings.JWTGroupsClaim,
+			GroupsPrefix:   settings.GlobalSettings.JWTGroupsPrefix,
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to set up authentication")
+			os.Exit(1)
+		}
+	case settings.TokenReviewAuthentication:
+		authenticator = &auth.TokenReviewAuthenticator{
+			Client:    mgr.GetClient(),
+			Audiences: settings.GlobalSettings.TokenReviewAudiences,
+		}
+	default:
+		setupLog.Info("WARNING: AUTHENTICATION is none, anyone who can reach the shim acts as the manager")
+	}
+	shimClient := auth.NewClient(mgr.GetClient(), mgr.GetConfig())
+
 	go func() {
 		log.Print("Starting shim handler")
 		appHandler := &handlers.AppHandler{
-			Client: mgr.GetClient(),
+			Client: shimClient,
 		}
 		packageHandler := &handlers.PackageHandler{
-			Client:          mgr.GetClient(),
+			Client:          shimClient,
 			KeychainFactory: keychainFactory,
 		}
 		buildHandler := &handlers.BuildHandler{
-			Client: mgr.GetClient(),
+			Client: shimClient,
+		}
+		dropletHandler := &handlers.DropletHandler{
+			Client: shimClient,
+		}
+		jobHandler := &handlers.JobHandler{
+			Client: shimClient,
+		}
+		auditEventHandler := &handlers.AuditEventHandler{
+			Client: shimClient,
 		}
 		myRouter := mux.NewRouter()
+		myRouter.Use(metrics.InstrumentRoutes, tracing.InstrumentRoutes)
+		if authenticator != nil {
+			myRouter.Use(handlers.Authenticate(authenticator))
+		}
 		myRouter.HandleFunc(handlers.GetAppEndpoint, appHandler.GetAppHandler).Methods("GET")
 		myRouter.HandleFunc(handlers.AppsEndpoint, appHandler.ListAppsHandler).Methods("GET")
 		myRouter.HandleFunc(handlers.AppsEndpoint, appHandler.CreateAppsHandler).Methods("POST")
 		myRouter.HandleFunc(handlers.SetAppDesiredStateEndpoint, appHandler.SetAppDesiredStateHandler).Methods("POST")
 		myRouter.HandleFunc(handlers.GetAppEndpoint, appHandler.UpdateAppsHandler).Methods("PUT")
+		myRouter.HandleFunc(handlers.GetAppEndpoint, appHandler.DeleteAppHandler).Methods("DELETE")
 		myRouter.HandleFunc(handlers.SetCurrentDroplet, appHandler.SetCurrentDroplet).Methods("PATCH")
+		myRouter.HandleFunc(handlers.AppPackagesEndpoint, packageHandler.ListAppPackagesHandler).Methods("GET")
+		myRouter.HandleFunc(handlers.PackageEndpoint, packageHandler.ListPackagesHandler).Methods("GET")
 		myRouter.HandleFunc(handlers.GetPackageEndpoint, packageHandler.GetPackageHandler).Methods("GET")
+		myRouter.HandleFunc(handlers.GetPackageEndpoint, packageHandler.DeletePackageHandler).Methods("DELETE")
+		myRouter.HandleFunc(handlers.PackageEndpoint, packageHandler.CopyPackageHandler).Methods("POST").Queries("source_guid", "{source_guid}")
 		myRouter.HandleFunc(handlers.PackageEndpoint, packageHandler.CreatePackageHandler).Methods("POST")
 		myRouter.HandleFunc(handlers.UploadPackageEndpoint, packageHandler.UploadPackageHandler).Methods("POST")
+		myRouter.HandleFunc(handlers.DownloadPackageEndpoint, packageHandler.DownloadPackageHandler).Methods("GET")
 		myRouter.HandleFunc(handlers.GetBuildsEndpoint, buildHandler.GetBuildHandler).Methods("GET")
+		myRouter.HandleFunc(handlers.GetBuildsEndpoint, buildHandler.DeleteBuildHandler).Methods("DELETE")
 		myRouter.HandleFunc(handlers.BuildsEndpoint, buildHandler.CreateBuildsHandler).Methods("POST")
+		myRouter.HandleFunc(handlers.GetDropletEndpoint, dropletHandler.GetDropletHandler).Methods("GET")
+		myRouter.HandleFunc(handlers.GetDropletEndpoint, dropletHandler.DeleteDropletHandler).Methods("DELETE")
+		myRouter.HandleFunc(handlers.GetJobEndpoint, jobHandler.GetJobHandler).Methods("GET")
+		myRouter.HandleFunc(handlers.AuditEventsEndpoint, auditEventHandler.ListAuditEventsHandler).Methods("GET")
+		myRouter.HandleFunc(handlers.GetAuditEventEndpoint, auditEventHandler.GetAuditEventHandler).Methods("GET")
 		log.Fatal(http.ListenAndServe(":9000", myRouter))
 	}()
 
@@ -197,5 +293,8 @@ func main() {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+	if err := shutdownTracing(context.Background()); err != nil {
+		setupLog.Error(err, "problem flushing spans")
+	}
 
 }
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// The metrics are registered with the controller-runtime registry, so the manager serves them on its metrics endpoint
+// (--metrics-bind-address) next to the controller-runtime ones, for the shim as well as for the reconcilers
+var (
+	// StagingDuration observes how long Builds took from the start of staging until they were STAGED or FAILED
+	StagingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cf_build_staging_duration_seconds",
+		Help:    "Time from the start of staging a build until it is staged or has failed",
+		Buckets: []float64{5, 15, 30, 60, 120, 180, 300, 600, 900},
+	}, []string{"lifecycle_type", "outcome"})
+
+	// BuildFailures counts failed Builds by the title of their error, e.g. CF-StagingError
+	BuildFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cf_build_failures_total",
+		Help: "Number of builds that failed staging, by reason",
+	}, []string{"reason"})
+
+	// ProcessDesiredInstances and ProcessRunningInstances compare the instances a Process should have with the ready
+	// instances of its LRP, a stopped App desires none
+	ProcessDesiredInstances = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cf_process_desired_instances",
+		Help: "Number of instances a process should be running",
+	}, []string{"namespace", "process_guid"})
+	ProcessRunningInstances = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cf_process_running_instances",
+		Help: "Number of ready instances of a process",
+	}, []string{"namespace", "process_guid"})
+
+	// APIRequestDuration observes the latency of the shim by route template, method and status code
+	APIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cf_api_request_duration_seconds",
+		Help:    "Latency of CF API shim requests",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		StagingDuration,
+		BuildFailures,
+		ProcessDesiredInstances,
+		ProcessRunningInstances,
+		APIRequestDuration,
+	)
+}
+
+// DeleteProcess stops reporting the instances of a Process that is gone
+func DeleteProcess(namespace string, processGUID string) {
+	ProcessDesiredInstances.DeleteLabelValues(namespace, processGUID)
+	ProcessRunningInstances.DeleteLabelValues(namespace, processGUID)
+}
+
+// InstrumentRoutes is a mux middleware that observes APIRequestDuration for every request
+// Routes are labelled by their template, e.g. /v3/apps/{guid}, so GUIDs do not end up in the label values
+func InstrumentRoutes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		APIRequestDuration.WithLabelValues(route, r.Method, strconv.Itoa(recorder.status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusRecorder remembers the status code a handler answered with, handlers that only write a body answer 200
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
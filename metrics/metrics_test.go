@@ -0,0 +1,37 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	"cloudfoundry.org/cf-crd-explorations/metrics"
+)
+
+func TestInstrumentRoutes(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(metrics.InstrumentRoutes)
+	router.HandleFunc("/v3/apps/{guid}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+	}).Methods("GET")
+
+	for _, guid := range []string{"app-a", "app-b"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v3/apps/"+guid, nil))
+	}
+
+	if count := testutil.CollectAndCount(metrics.APIRequestDuration); count != 1 {
+		t.Errorf("expected both requests in a single series of the route template, got %d series", count)
+	}
+	sample := &dto.Metric{}
+	if err := metrics.APIRequestDuration.WithLabelValues("/v3/apps/{guid}", "GET", "404").(prometheus.Metric).Write(sample); err != nil {
+		t.Fatal(err)
+	}
+	if count := sample.GetHistogram().GetSampleCount(); count != 2 {
+		t.Errorf("expected both requests under their route, method and status, got %d", count)
+	}
+}
@@ -0,0 +1,79 @@
+package settings
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Defaults holds the values applied to Apps and Processes when they are created without them,
+// whether they come from the shim or are applied directly with kubectl
+type Defaults struct {
+	Stack           string
+	LifecycleType   string
+	DesiredState    string
+	MemoryMB        int64
+	DiskQuotaMB     int64
+	Port            int32
+	HealthCheckType string
+}
+
+var GlobalDefaults = BuiltinDefaults()
+
+// BuiltinDefaults returns the built-in defaults, used for anything not overridden in the environment
+func BuiltinDefaults() *Defaults {
+	return &Defaults{
+		Stack:           "cflinuxfs3", // TODO: This is the default in CF for VMs. What should the default stack be here?
+		LifecycleType:   "buildpack",
+		DesiredState:    "STOPPED",
+		MemoryMB:        500, // TODO: find CF default values
+		DiskQuotaMB:     512, // TODO: find CF default values
+		Port:            8080,
+		HealthCheckType: "process",
+	}
+}
+
+// LoadDefaults reads the DEFAULT_* environment variables on top of the built-in defaults
+func LoadDefaults() (*Defaults, error) {
+	d := BuiltinDefaults()
+
+	if value, exists := os.LookupEnv("DEFAULT_STACK"); exists {
+		d.Stack = value
+	}
+	if value, exists := os.LookupEnv("DEFAULT_LIFECYCLE_TYPE"); exists {
+		d.LifecycleType = value
+	}
+	if value, exists := os.LookupEnv("DEFAULT_APP_STATE"); exists {
+		d.DesiredState = value
+	}
+	if value, exists := os.LookupEnv("DEFAULT_HEALTH_CHECK_TYPE"); exists {
+		d.HealthCheckType = value
+	}
+
+	var err error
+	if d.MemoryMB, err = lookupInt64("DEFAULT_MEMORY_MB", d.MemoryMB); err != nil {
+		return nil, err
+	}
+	if d.DiskQuotaMB, err = lookupInt64("DEFAULT_DISK_QUOTA_MB", d.DiskQuotaMB); err != nil {
+		return nil, err
+	}
+	port, err := lookupInt64("DEFAULT_PORT", int64(d.Port))
+	if err != nil {
+		return nil, err
+	}
+	d.Port = int32(port)
+
+	return d, nil
+}
+
+func lookupInt64(key string, fallback int64) (int64, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback, nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return parsed, nil
+}
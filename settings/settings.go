@@ -2,7 +2,19 @@ package settings
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strings"
+)
+
+// The ways the shim can authenticate requests, set with AUTHENTICATION
+const (
+	// JWTAuthentication verifies JWT bearer tokens, like the ones UAA issues, with the keys of JWT_JWKS_URL
+	JWTAuthentication = "jwt"
+	// TokenReviewAuthentication asks the Kubernetes API server whether it accepts the bearer token
+	TokenReviewAuthentication = "tokenreview"
+	// NoAuthentication lets anyone who can reach the shim act as the manager
+	NoAuthentication = "none"
 )
 
 var GlobalSettings *Settings
@@ -12,6 +24,23 @@ type Settings struct {
 	RegistryTagBase     string `json:"registryTagBase"`
 	RegistrySecret      string
 	PackageRegistryBase string
+	// MaxRetainedDroplets and MaxRetainedPackages are how many of the newest Droplets and Packages of an App are kept,
+	// older ones are deleted along with their images. 0 keeps all of them
+	MaxRetainedDroplets int64
+	MaxRetainedPackages int64
+
+	// Authentication is how the shim authenticates requests, see JWTAuthentication and TokenReviewAuthentication
+	Authentication string
+	// JWT* configure JWTAuthentication, see auth.JWTConfig
+	JWTJWKSURL        string
+	JWTIssuer         string
+	JWTAudience       string
+	JWTUsernameClaim  string
+	JWTUsernamePrefix string
+	JWTGroupsClaim    string
+	JWTGroupsPrefix   string
+	// TokenReviewAudiences are the audiences bearer tokens must be issued for with TokenReviewAuthentication
+	TokenReviewAudiences []string
 }
 
 func Load() (*Settings, error) {
@@ -32,5 +61,46 @@ func Load() (*Settings, error) {
 		return nil, errors.New("REGISTRY_SECRET not configured")
 	}
 
+	var err error
+	if s.MaxRetainedDroplets, err = lookupInt64("MAX_RETAINED_DROPLETS", 5); err != nil {
+		return nil, err
+	}
+	if s.MaxRetainedPackages, err = lookupInt64("MAX_RETAINED_PACKAGES", 5); err != nil {
+		return nil, err
+	}
+
+	s.Authentication = os.Getenv("AUTHENTICATION")
+	switch s.Authentication {
+	case JWTAuthentication:
+		s.JWTJWKSURL, exists = os.LookupEnv("JWT_JWKS_URL")
+		if !exists {
+			return nil, errors.New("JWT_JWKS_URL not configured")
+		}
+		s.JWTAudience = os.Getenv("JWT_AUDIENCE")
+		if s.JWTAudience == "" {
+			return nil, errors.New("JWT_AUDIENCE not configured")
+		}
+		s.JWTIssuer = os.Getenv("JWT_ISSUER")
+		s.JWTUsernameClaim = lookupString("JWT_USERNAME_CLAIM", "user_name")
+		s.JWTUsernamePrefix = lookupString("JWT_USERNAME_PREFIX", "uaa:")
+		s.JWTGroupsClaim = os.Getenv("JWT_GROUPS_CLAIM")
+		s.JWTGroupsPrefix = lookupString("JWT_GROUPS_PREFIX", s.JWTUsernamePrefix)
+	case TokenReviewAuthentication:
+		if audiences := os.Getenv("TOKEN_REVIEW_AUDIENCES"); audiences != "" {
+			s.TokenReviewAudiences = strings.Split(audiences, ",")
+		}
+	case "", NoAuthentication:
+		s.Authentication = NoAuthentication
+	default:
+		return nil, fmt.Errorf("AUTHENTICATION %q is not supported, use %s, %s or %s", s.Authentication, JWTAuthentication, TokenReviewAuthentication, NoAuthentication)
+	}
+
 	return s, nil
 }
+
+func lookupString(key string, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	tracerName  = "cloudfoundry.org/cf-crd-explorations"
+	serviceName = "cf-crd-explorations"
+
+	// annotationPrefix is prepended to the W3C trace context fields, traceparent and tracestate, in the annotations of
+	// the objects a traced request or reconcile creates or updates
+	annotationPrefix = "apps.cloudfoundry.org/"
+)
+
+// propagator carries the trace context across HTTP requests and, through annotations, from the shim to the reconcilers
+var propagator = propagation.TraceContext{}
+
+// Setup installs the global TracerProvider that exports spans as configured by OTEL_TRACES_EXPORTER:
+// otlp sends them to OTEL_EXPORTER_OTLP_ENDPOINT over HTTP, console prints them for local runs, and anything else,
+// including not setting it, leaves tracing off
+// The returned func flushes the spans that have not been exported yet
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch exporterName := os.Getenv("OTEL_TRACES_EXPORTER"); exporterName {
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "console":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	default:
+		return nil, fmt.Errorf("OTEL_TRACES_EXPORTER %q is not supported, use otlp or console", exporterName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating span exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// InstrumentRoutes is a mux middleware that traces every request, continuing the trace of the client if it sent one
+// Spans are named after the method and route template, e.g. POST /v3/builds
+func InstrumentRoutes(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "cf-api-shim", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				return r.Method + " " + template
+			}
+		}
+		return r.Method
+	}))
+}
+
+// InjectAnnotations writes the trace context of ctx into the annotations of obj before it is created or updated, so the
+// reconciles it causes join the trace of the request or reconcile that changed it. Without a span in ctx it does nothing
+func InjectAnnotations(ctx context.Context, obj metav1.Object) {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for key, value := range carrier {
+		annotations[annotationPrefix+key] = value
+	}
+	obj.SetAnnotations(annotations)
+}
+
+// StartReconcile starts the span of a reconcile of obj, as a child of the trace context in the annotations of from,
+// usually obj itself. Objects nothing traced has changed start a new trace
+func StartReconcile(ctx context.Context, controllerName string, obj metav1.Object, from metav1.Object) (context.Context, trace.Span) {
+	carrier := propagation.MapCarrier{}
+	for key, value := range from.GetAnnotations() {
+		if strings.HasPrefix(key, annotationPrefix) {
+			carrier[strings.TrimPrefix(key, annotationPrefix)] = value
+		}
+	}
+	ctx = propagator.Extract(ctx, carrier)
+
+	return otel.Tracer(tracerName).Start(ctx, controllerName+" reconcile", trace.WithAttributes(
+		attribute.String("k8s.namespace.name", obj.GetNamespace()),
+		attribute.String("k8s.object.name", obj.GetName()),
+		attribute.String("k8s.object.resource_version", obj.GetResourceVersion()),
+	))
+}
@@ -0,0 +1,41 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/tracing"
+)
+
+func TestReconcileJoinsTraceOfAnnotations(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer provider.Shutdown(context.Background())
+
+	build := &appsv1alpha1.Build{ObjectMeta: metav1.ObjectMeta{Name: "build-guid", Namespace: "default"}}
+	tracing.InjectAnnotations(context.Background(), build)
+	if len(build.Annotations) != 0 {
+		t.Errorf("expected no annotations without a span, got %v", build.Annotations)
+	}
+
+	requestCtx, requestSpan := provider.Tracer("test").Start(context.Background(), "POST /v3/builds")
+	tracing.InjectAnnotations(requestCtx, build)
+	requestSpan.End()
+
+	_, reconcileSpan := tracing.StartReconcile(context.Background(), "BuildReconciler", build, build)
+	defer reconcileSpan.End()
+	if reconcileSpan.SpanContext().TraceID() != requestSpan.SpanContext().TraceID() {
+		t.Errorf("expected the reconcile to join trace %s, got %s", requestSpan.SpanContext().TraceID(), reconcileSpan.SpanContext().TraceID())
+	}
+
+	_, unrelatedSpan := tracing.StartReconcile(context.Background(), "BuildReconciler", build, &appsv1alpha1.App{})
+	defer unrelatedSpan.End()
+	if unrelatedSpan.SpanContext().TraceID() == requestSpan.SpanContext().TraceID() {
+		t.Error("expected a reconcile of an object without trace annotations to start a new trace")
+	}
+}
@@ -0,0 +1,351 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	CACertName = "ca.crt"
+	CertName   = "tls.crt"
+	KeyName    = "tls.key"
+	// PreviousCACertName keeps the CA that was replaced by the last rotation, see caOverlap
+	PreviousCACertName = "previous-ca.crt"
+	// RotatedAtAnnotation records when the certificates in the Secret replaced the previous ones
+	RotatedAtAnnotation = "apps.cloudfoundry.org/certs-rotated-at"
+
+	// certValidity is how long generated certificates are valid for
+	certValidity = 365 * 24 * time.Hour
+	// rotationWindow is how long before expiry certificates get replaced
+	rotationWindow = 30 * 24 * time.Hour
+	// checkInterval is how often the certificates are checked for upcoming expiry
+	checkInterval = 12 * time.Hour
+	// caOverlap is how long the previous CA stays in the caBundle after a rotation. Every replica loads the new
+	// certificates within checkInterval, until then the ones still serving the previous certificate must be trusted
+	caOverlap = 2 * checkInterval
+)
+
+// Rotator generates a self-signed CA and serving certificate for the webhook server, keeps them in a Secret so that
+// restarts reuse them, writes them to CertDir for the webhook server and injects the CA into the caBundle of the
+// webhook configurations and the conversion webhook of the CRDs. Certificates are replaced shortly before they expire,
+// the previous CA stays in the caBundle until every replica serves the new certificate.
+type Rotator struct {
+	// Client must not depend on the manager cache, since the certificates are needed before the manager starts
+	Client client.Client
+
+	SecretKey types.NamespacedName
+	CertDir   string
+	// DNSName is the name the kube-apiserver uses to reach the webhook Service, e.g. <service>.<namespace>.svc
+	DNSName string
+
+	ValidatingWebhookConfigurations []string
+	MutatingWebhookConfigurations   []string
+	// CustomResourceDefinitions are CRDs converted by the webhook server, they need the CA for spec.conversion.webhook
+	CustomResourceDefinitions []string
+
+	mu    sync.Mutex
+	ready bool
+}
+
+// EnsureCerts makes sure valid certificates exist in the Secret, in CertDir and in every configured caBundle
+func (r *Rotator) EnsureCerts(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	secret, err := r.ensureSecret(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The new CA is trusted before this replica serves a certificate signed by it
+	if err := r.injectCABundle(ctx, caBundle(secret)); err != nil {
+		return err
+	}
+
+	if err := r.writeCertDir(secret); err != nil {
+		return err
+	}
+
+	r.ready = true
+	return nil
+}
+
+// Start checks the certificates periodically until the context is cancelled, see manager.Runnable
+func (r *Rotator) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("cert-rotator")
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.EnsureCerts(ctx); err != nil {
+				logger.Error(err, "failed to refresh webhook certificates")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection is false since every replica needs the certificates on its own disk
+func (r *Rotator) NeedLeaderElection() bool {
+	return false
+}
+
+// ReadyCheck reports ready once the certificates have been written, see healthz.Checker
+func (r *Rotator) ReadyCheck(_ *http.Request) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.ready {
+		return errors.New("webhook certificates have not been generated yet")
+	}
+	return nil
+}
+
+func (r *Rotator) ensureSecret(ctx context.Context) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, r.SecretKey, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err == nil && r.certsValid(secret) {
+		if _, ok := secret.Data[PreviousCACertName]; !ok || inOverlap(secret, now) {
+			return secret, nil
+		}
+		// Every replica serves the current certificate by now, the previous CA is no longer needed
+		delete(secret.Data, PreviousCACertName)
+		delete(secret.Annotations, RotatedAtAnnotation)
+		return r.updateSecret(ctx, secret)
+	}
+
+	data, genErr := generateCerts(r.DNSName, now)
+	if genErr != nil {
+		return nil, genErr
+	}
+
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      r.SecretKey.Name,
+				Namespace: r.SecretKey.Namespace,
+			},
+			Type: corev1.SecretTypeTLS,
+			Data: data,
+		}
+		if err := r.Client.Create(ctx, secret); err != nil {
+			// Another replica got there first, use its certificates
+			if apierrors.IsAlreadyExists(err) {
+				return secret, r.Client.Get(ctx, r.SecretKey, secret)
+			}
+			return nil, err
+		}
+		return secret, nil
+	}
+
+	// The replicas that have not loaded the new certificates yet still serve ones signed by the previous CA
+	if previousCA := secret.Data[CACertName]; len(previousCA) > 0 {
+		data[PreviousCACertName] = previousCA
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[RotatedAtAnnotation] = now.UTC().Format(time.RFC3339)
+	}
+	secret.Data = data
+	return r.updateSecret(ctx, secret)
+}
+
+// updateSecret stores the Secret, or uses the Secret of another replica that updated it first
+func (r *Rotator) updateSecret(ctx context.Context, secret *corev1.Secret) (*corev1.Secret, error) {
+	if err := r.Client.Update(ctx, secret); err != nil {
+		if apierrors.IsConflict(err) {
+			return secret, r.Client.Get(ctx, r.SecretKey, secret)
+		}
+		return nil, err
+	}
+	return secret, nil
+}
+
+// inOverlap is true until caOverlap has passed since the certificates in the Secret were rotated
+func inOverlap(secret *corev1.Secret, now time.Time) bool {
+	rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[RotatedAtAnnotation])
+	if err != nil {
+		return false
+	}
+	return now.Before(rotatedAt.Add(caOverlap))
+}
+
+// caBundle is the CA of the Secret, along with the previous one while replicas may still serve certificates signed by it
+func caBundle(secret *corev1.Secret) []byte {
+	return append(append([]byte{}, secret.Data[CACertName]...), secret.Data[PreviousCACertName]...)
+}
+
+// certsValid is false if the Secret is missing any of the certificates, they are for another DNS name or they are about to expire
+func (r *Rotator) certsValid(secret *corev1.Secret) bool {
+	if len(secret.Data[CACertName]) == 0 || len(secret.Data[KeyName]) == 0 {
+		return false
+	}
+
+	block, _ := pem.Decode(secret.Data[CertName])
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+
+	if cert.VerifyHostname(r.DNSName) != nil {
+		return false
+	}
+	return time.Now().Add(rotationWindow).Before(cert.NotAfter)
+}
+
+// writeCertDir only touches the files when they changed, the webhook server reloads them when they do
+func (r *Rotator) writeCertDir(secret *corev1.Secret) error {
+	if err := os.MkdirAll(r.CertDir, 0700); err != nil {
+		return err
+	}
+
+	for _, name := range []string{CertName, KeyName} {
+		path := filepath.Join(r.CertDir, name)
+		if existing, err := ioutil.ReadFile(path); err == nil && bytes.Equal(existing, secret.Data[name]) {
+			continue
+		}
+
+		// Write to a temporary file and rename it so the webhook server never reads a partial file
+		tmpPath := path + ".tmp"
+		if err := ioutil.WriteFile(tmpPath, secret.Data[name], 0600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmpPath, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Rotator) injectCABundle(ctx context.Context, caBundle []byte) error {
+	for _, name := range r.ValidatingWebhookConfigurations {
+		config := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: name}, config); err != nil {
+			return fmt.Errorf("cannot inject caBundle into ValidatingWebhookConfiguration %s: %w", name, err)
+		}
+
+		updated := config.DeepCopy()
+		for i := range updated.Webhooks {
+			updated.Webhooks[i].ClientConfig.CABundle = caBundle
+		}
+		if err := r.Client.Patch(ctx, updated, client.MergeFrom(config)); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range r.MutatingWebhookConfigurations {
+		config := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: name}, config); err != nil {
+			return fmt.Errorf("cannot inject caBundle into MutatingWebhookConfiguration %s: %w", name, err)
+		}
+
+		updated := config.DeepCopy()
+		for i := range updated.Webhooks {
+			updated.Webhooks[i].ClientConfig.CABundle = caBundle
+		}
+		if err := r.Client.Patch(ctx, updated, client.MergeFrom(config)); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range r.CustomResourceDefinitions {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: name}, crd); err != nil {
+			return fmt.Errorf("cannot inject caBundle into CustomResourceDefinition %s: %w", name, err)
+		}
+		// CRDs installed without the conversion patches have nothing to inject into
+		if crd.Spec.Conversion == nil || crd.Spec.Conversion.Webhook == nil || crd.Spec.Conversion.Webhook.ClientConfig == nil {
+			continue
+		}
+
+		updated := crd.DeepCopy()
+		updated.Spec.Conversion.Webhook.ClientConfig.CABundle = caBundle
+		if err := r.Client.Patch(ctx, updated, client.MergeFrom(crd)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateCerts creates a new CA and a serving certificate for dnsName signed by it, in the layout of a kubernetes.io/tls Secret
+func generateCerts(dnsName string, now time.Time) (map[string][]byte, error) {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(now.UnixNano()),
+		Subject:               pkix.Name{CommonName: dnsName + "-ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(certValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(now.UnixNano() + 1),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(certValidity),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		CACertName: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		CertName:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		KeyName:    pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+	}, nil
+}
@@ -0,0 +1,155 @@
+package certs_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cloudfoundry.org/cf-crd-explorations/webhooks/certs"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEnsureCerts(t *testing.T) {
+	validatingConfig := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-validation-webhook"},
+		Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "app-validation-webhook.default.svc"}},
+	}
+	mutatingConfig := &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-defaulting-webhook"},
+		Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: "app-defaulting-webhook.default.svc"}},
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps.apps.cloudfoundry.org"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Conversion: &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.WebhookConverter,
+				Webhook: &apiextensionsv1.WebhookConversion{
+					ClientConfig:             &apiextensionsv1.WebhookClientConfig{},
+					ConversionReviewVersions: []string{"v1"},
+				},
+			},
+		},
+	}
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(validatingConfig, mutatingConfig, crd).Build()
+
+	rotator := &certs.Rotator{
+		Client:                          kubeClient,
+		SecretKey:                       types.NamespacedName{Name: "app-validation-webhook-certs", Namespace: "default"},
+		CertDir:                         t.TempDir(),
+		DNSName:                         "app-validation-webhook.default.svc",
+		ValidatingWebhookConfigurations: []string{"app-validation-webhook"},
+		MutatingWebhookConfigurations:   []string{"app-defaulting-webhook"},
+		CustomResourceDefinitions:       []string{"apps.apps.cloudfoundry.org"},
+	}
+	if err := rotator.ReadyCheck(nil); err == nil {
+		t.Errorf("expected not to be ready before the certificates are generated")
+	}
+
+	ctx := context.Background()
+	if err := rotator.EnsureCerts(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := rotator.ReadyCheck(nil); err != nil {
+		t.Errorf("expected to be ready, got %v", err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := kubeClient.Get(ctx, rotator.SecretKey, secret); err != nil {
+		t.Fatalf("expected the certificate Secret to be created: %v", err)
+	}
+
+	servingCert, err := ioutil.ReadFile(filepath.Join(rotator.CertDir, certs.CertName))
+	if err != nil || !bytes.Equal(servingCert, secret.Data[certs.CertName]) {
+		t.Errorf("expected the serving certificate to be written to the cert dir, got %v", err)
+	}
+
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: "app-validation-webhook"}, validatingConfig); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(validatingConfig.Webhooks[0].ClientConfig.CABundle, secret.Data[certs.CACertName]) {
+		t.Errorf("expected the CA to be injected into the ValidatingWebhookConfiguration")
+	}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: "app-defaulting-webhook"}, mutatingConfig); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(mutatingConfig.Webhooks[0].ClientConfig.CABundle, secret.Data[certs.CACertName]) {
+		t.Errorf("expected the CA to be injected into the MutatingWebhookConfiguration")
+	}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: "apps.apps.cloudfoundry.org"}, crd); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(crd.Spec.Conversion.Webhook.ClientConfig.CABundle, secret.Data[certs.CACertName]) {
+		t.Errorf("expected the CA to be injected into the CustomResourceDefinition conversion webhook")
+	}
+
+	// Valid certificates are reused rather than regenerated on restart
+	if err := rotator.EnsureCerts(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reused := &corev1.Secret{}
+	if err := kubeClient.Get(ctx, rotator.SecretKey, reused); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reused.Data[certs.CertName], secret.Data[certs.CertName]) {
+		t.Errorf("expected the existing certificate to be reused")
+	}
+
+	// Certificates for another name are rotated, the previous CA stays trusted while replicas still serve the previous certificate
+	rotator.DNSName = "app-validation-webhook.other.svc"
+	if err := rotator.EnsureCerts(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rotated := &corev1.Secret{}
+	if err := kubeClient.Get(ctx, rotator.SecretKey, rotated); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(rotated.Data[certs.CACertName], secret.Data[certs.CACertName]) {
+		t.Fatalf("expected a new CA")
+	}
+	if !bytes.Equal(rotated.Data[certs.PreviousCACertName], secret.Data[certs.CACertName]) {
+		t.Errorf("expected the previous CA to be kept")
+	}
+	bothCAs := append(append([]byte{}, rotated.Data[certs.CACertName]...), secret.Data[certs.CACertName]...)
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: "app-validation-webhook"}, validatingConfig); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(validatingConfig.Webhooks[0].ClientConfig.CABundle, bothCAs) {
+		t.Errorf("expected both CAs to be injected during the overlap")
+	}
+
+	// The previous CA is dropped once every replica had the time to load the new certificates
+	rotated.Annotations[certs.RotatedAtAnnotation] = time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	if err := kubeClient.Update(ctx, rotated); err != nil {
+		t.Fatal(err)
+	}
+	if err := rotator.EnsureCerts(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	settled := &corev1.Secret{}
+	if err := kubeClient.Get(ctx, rotator.SecretKey, settled); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := settled.Data[certs.PreviousCACertName]; ok || !bytes.Equal(settled.Data[certs.CACertName], rotated.Data[certs.CACertName]) {
+		t.Errorf("expected only the new CA to be kept")
+	}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: "apps.apps.cloudfoundry.org"}, crd); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(crd.Spec.Conversion.Webhook.ClientConfig.CABundle, rotated.Data[certs.CACertName]) {
+		t.Errorf("expected only the new CA to be injected after the overlap")
+	}
+}
@@ -1,106 +1,154 @@
 package main
 
 import (
-	"crypto/tls"
+	"context"
 	"flag"
 	"fmt"
-	"log"
-	"net/http"
 	"os"
 	"path/filepath"
-	"time"
 
 	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	appsv1beta1 "cloudfoundry.org/cf-crd-explorations/api/v1beta1"
+	"cloudfoundry.org/cf-crd-explorations/settings"
+	"cloudfoundry.org/cf-crd-explorations/webhooks/certs"
+	"cloudfoundry.org/cf-crd-explorations/webhooks/mutate"
 	"cloudfoundry.org/cf-crd-explorations/webhooks/validate"
-	"github.com/gorilla/mux"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/homedir"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
 )
 
 var (
-	config    *rest.Config
-	clientSet *kubernetes.Clientset
-	scheme    = runtime.NewScheme()
+	scheme   = runtime.NewScheme()
+	setupLog = ctrl.Log.WithName("setup")
 )
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 
 	utilruntime.Must(appsv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(appsv1beta1.AddToScheme(scheme))
 
 	//+kubebuilder:scaffold:scheme
 }
 
 func main() {
-	var tlscert string
-	var tlskey string
-
-	flag.StringVar(&tlscert, "tlsCertFile", "/etc/certs/cert.pem", "File containing the x509 Certificate for HTTPS.")
-	flag.StringVar(&tlskey, "tlsKeyFile", "/etc/certs/key.pem", "File containing the x509 private key to --tlsCertFile.")
-
+	var webhookPort int
+	var certDir string
+	var serviceName string
+	var namespace string
+	var probeAddr string
+	var metricsAddr string
+
+	// The port is also the targetPort of the Service in config/webhook/app-validation.yaml
+	flag.IntVar(&webhookPort, "webhook-port", 9082, "The port the webhook server listens on.")
+	flag.StringVar(&certDir, "cert-dir", filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs"), "The directory the generated serving certificates are written to.")
+	flag.StringVar(&serviceName, "service-name", "app-validation-webhook", "The name of the Service in front of the webhook server.")
+	flag.StringVar(&namespace, "namespace", envOrDefault("POD_NAMESPACE", "default"), "The namespace of the webhook Service and of the certificate Secret.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
-	_, err := tls.LoadX509KeyPair(tlscert, tlskey)
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	// defaults applied by the mutating webhooks can be overridden from env variables
+	defaults, err := settings.LoadDefaults()
 	if err != nil {
-		fmt.Printf("Failed to load key pair: %v", err)
+		setupLog.Error(err, "unable to load defaults")
+		os.Exit(1)
 	}
 
-	useKubeConfig := os.Getenv("USE_KUBECONFIG")
-	kubeConfigFilePath := os.Getenv("KUBECONFIG")
-
-	if len(useKubeConfig) == 0 {
-		// default to service account in cluster token
-		c, err := rest.InClusterConfig()
-		if err != nil {
-			panic(err.Error())
-		}
-		config = c
-	} else {
-		//load from a kube config
-		var kubeconfig string
-
-		if kubeConfigFilePath == "" {
-			if home := homedir.HomeDir(); home != "" {
-				kubeconfig = filepath.Join(home, ".kube", "config")
-			}
-		} else {
-			kubeconfig = kubeConfigFilePath
-		}
-
-		fmt.Println("kubeconfig: " + kubeconfig)
-
-		c, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
-		if err != nil {
-			panic(err.Error())
-		}
-		config = c
+	config := ctrl.GetConfigOrDie()
+	mgr, err := ctrl.NewManager(config, ctrl.Options{
+		Scheme:                 scheme,
+		MetricsBindAddress:     metricsAddr,
+		Port:                   webhookPort,
+		CertDir:                certDir,
+		HealthProbeBindAddress: probeAddr,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
 	}
 
-	// creates the kubeclient
-	kubeclient, err := client.New(config, client.Options{Scheme: scheme})
+	// The manager cache is not running yet, so the rotator gets a client that talks to the API server directly
+	// The App name check uses it too, since it must not act on a stale view of the App name reservations
+	directClient, err := client.New(config, client.Options{Scheme: scheme})
 	if err != nil {
-		panic(err.Error())
+		setupLog.Error(err, "unable to create client")
+		os.Exit(1)
 	}
-	go func() {
-		fmt.Println("********** Starting app validation webhook **************")
-		webhook := &validate.AppValidator{
-			KubeClient: kubeclient,
-		}
 
-		myRouter := mux.NewRouter()
-		myRouter.HandleFunc("/validate", webhook.AppValidation)
+	rotator := &certs.Rotator{
+		Client:                          directClient,
+		SecretKey:                       types.NamespacedName{Name: serviceName + "-certs", Namespace: namespace},
+		CertDir:                         certDir,
+		DNSName:                         fmt.Sprintf("%s.%s.svc", serviceName, namespace),
+		ValidatingWebhookConfigurations: []string{"app-validation-webhook"},
+		MutatingWebhookConfigurations:   []string{"app-defaulting-webhook"},
+		CustomResourceDefinitions: []string{
+			"apps.apps.cloudfoundry.org",
+			"processes.apps.cloudfoundry.org",
+			"packages.apps.cloudfoundry.org",
+			"builds.apps.cloudfoundry.org",
+			"droplets.apps.cloudfoundry.org",
+			"appmanifests.apps.cloudfoundry.org",
+		},
+	}
+	// The webhook server loads its certificates on start, so they have to exist before the manager starts
+	if err := rotator.EnsureCerts(context.Background()); err != nil {
+		setupLog.Error(err, "unable to generate webhook certificates")
+		os.Exit(1)
+	}
+	if err := mgr.Add(rotator); err != nil {
+		setupLog.Error(err, "unable to set up certificate rotation")
+		os.Exit(1)
+	}
 
-		log.Fatal(http.ListenAndServeTLS(":9082", tlscert, tlskey, myRouter))
-	}()
+	hookServer := mgr.GetWebhookServer()
+	hookServer.Register("/validate", &webhook.Admission{Handler: &validate.AppValidator{KubeClient: directClient}})
+	hookServer.Register("/validate-package", &webhook.Admission{Handler: &validate.PackageValidator{}})
+	hookServer.Register("/validate-build", &webhook.Admission{Handler: &validate.BuildValidator{KubeClient: mgr.GetClient()}})
+	hookServer.Register("/validate-droplet", &webhook.Admission{Handler: &validate.DropletValidator{KubeClient: mgr.GetClient()}})
+	hookServer.Register("/validate-process", &webhook.Admission{Handler: &validate.ProcessValidator{}})
+	hookServer.Register("/mutate", &webhook.Admission{Handler: &mutate.AppDefaulter{Defaults: defaults}})
+	hookServer.Register("/mutate-process", &webhook.Admission{Handler: &mutate.ProcessDefaulter{Defaults: defaults}})
+	// Converts between the served API versions through the Hub (v1beta1), see api/v1alpha1/conversion.go
+	hookServer.Register("/convert", &conversion.Webhook{})
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up health check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("readyz", rotator.ReadyCheck); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
 
-	//this is a hack for stopping main thread from terminating
-	time.Sleep(60 * time.Minute)
+	setupLog.Info("starting validation, defaulting and conversion webhooks")
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		setupLog.Error(err, "problem running webhooks")
+		os.Exit(1)
+	}
+}
 
+func envOrDefault(key, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return fallback
 }
@@ -0,0 +1,50 @@
+package mutate
+
+import (
+	"context"
+	"net/http"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/settings"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// AppDefaulter fills in the CF defaults for fields left empty on an App
+type AppDefaulter struct {
+	Defaults *settings.Defaults
+	decoder  *admission.Decoder
+}
+
+func (a *AppDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	app := &appsv1alpha1.App{}
+	if err := a.decoder.Decode(req, app); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	DefaultApp(app, a.Defaults)
+	return patchResponse(req, app)
+}
+
+// InjectDecoder is called by the webhook server, see admission.DecoderInjector
+func (a *AppDefaulter) InjectDecoder(d *admission.Decoder) error {
+	a.decoder = d
+	return nil
+}
+
+// DefaultApp sets the lifecycle type, stack, buildpacks and desired state of the App if they are empty
+func DefaultApp(app *appsv1alpha1.App, defaults *settings.Defaults) {
+	if app.Spec.DesiredState == "" {
+		app.Spec.DesiredState = appsv1alpha1.DesiredState(defaults.DesiredState)
+	}
+	if app.Spec.Type == "" {
+		app.Spec.Type = appsv1alpha1.LifecycleType(defaults.LifecycleType)
+	}
+	if app.Spec.Type == appsv1alpha1.BuildpackLifecycle {
+		if app.Spec.Lifecycle.Data.Stack == "" {
+			app.Spec.Lifecycle.Data.Stack = defaults.Stack
+		}
+		if app.Spec.Lifecycle.Data.Buildpacks == nil {
+			app.Spec.Lifecycle.Data.Buildpacks = []string{}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package mutate_test
+
+import (
+	"testing"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/settings"
+	"cloudfoundry.org/cf-crd-explorations/webhooks/mutate"
+)
+
+func TestDefaultApp(t *testing.T) {
+	app := &appsv1alpha1.App{}
+	mutate.DefaultApp(app, settings.BuiltinDefaults())
+
+	if app.Spec.Type != appsv1alpha1.BuildpackLifecycle || app.Spec.DesiredState != appsv1alpha1.StoppedState {
+		t.Errorf("expected a stopped buildpack app, got %s/%s", app.Spec.Type, app.Spec.DesiredState)
+	}
+	if app.Spec.Lifecycle.Data.Stack != "cflinuxfs3" || app.Spec.Lifecycle.Data.Buildpacks == nil {
+		t.Errorf("expected the default stack and empty buildpacks, got %+v", app.Spec.Lifecycle.Data)
+	}
+
+	dockerApp := &appsv1alpha1.App{Spec: appsv1alpha1.AppSpec{Type: appsv1alpha1.DockerLifecycle}}
+	mutate.DefaultApp(dockerApp, settings.BuiltinDefaults())
+	if dockerApp.Spec.Lifecycle.Data.Stack != "" {
+		t.Errorf("expected no stack for a docker app, got %s", dockerApp.Spec.Lifecycle.Data.Stack)
+	}
+}
+
+func TestDefaultProcess(t *testing.T) {
+	defaults := settings.BuiltinDefaults()
+	defaults.MemoryMB = 1024
+
+	process := &appsv1alpha1.Process{Spec: appsv1alpha1.ProcessSpec{DiskQuotaMB: 2048}}
+	mutate.DefaultProcess(process, defaults)
+
+	if process.Spec.MemoryMB != 1024 || process.Spec.DiskQuotaMB != 2048 {
+		t.Errorf("expected memory from config and disk left alone, got %d/%d", process.Spec.MemoryMB, process.Spec.DiskQuotaMB)
+	}
+	if len(process.Spec.Ports) != 1 || process.Spec.Ports[0] != 8080 {
+		t.Errorf("expected the default port, got %v", process.Spec.Ports)
+	}
+	if process.Spec.HealthCheck.Type != appsv1alpha1.ProcessHealthCheckType || process.Spec.State != appsv1alpha1.StoppedState {
+		t.Errorf("expected a stopped process with a process health check, got %s/%s", process.Spec.HealthCheck.Type, process.Spec.State)
+	}
+}
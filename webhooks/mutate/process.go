@@ -0,0 +1,52 @@
+package mutate
+
+import (
+	"context"
+	"net/http"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/settings"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ProcessDefaulter fills in the CF defaults for fields left empty on a Process
+type ProcessDefaulter struct {
+	Defaults *settings.Defaults
+	decoder  *admission.Decoder
+}
+
+func (p *ProcessDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	process := &appsv1alpha1.Process{}
+	if err := p.decoder.Decode(req, process); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	DefaultProcess(process, p.Defaults)
+	return patchResponse(req, process)
+}
+
+// InjectDecoder is called by the webhook server, see admission.DecoderInjector
+func (p *ProcessDefaulter) InjectDecoder(d *admission.Decoder) error {
+	p.decoder = d
+	return nil
+}
+
+// DefaultProcess sets the state, health check type, memory, disk and ports of the Process if they are empty
+// Instances are left alone since 0 is a meaningful value that cannot be told apart from an unset field
+func DefaultProcess(process *appsv1alpha1.Process, defaults *settings.Defaults) {
+	if process.Spec.State == "" {
+		process.Spec.State = appsv1alpha1.DesiredState(defaults.DesiredState)
+	}
+	if process.Spec.HealthCheck.Type == "" {
+		process.Spec.HealthCheck.Type = appsv1alpha1.HealthCheckType(defaults.HealthCheckType)
+	}
+	if process.Spec.MemoryMB == 0 {
+		process.Spec.MemoryMB = defaults.MemoryMB
+	}
+	if process.Spec.DiskQuotaMB == 0 {
+		process.Spec.DiskQuotaMB = defaults.DiskQuotaMB
+	}
+	if len(process.Spec.Ports) == 0 {
+		process.Spec.Ports = []int32{defaults.Port}
+	}
+}
@@ -0,0 +1,17 @@
+package mutate
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// patchResponse answers with the JSON patch that turns the object under review into the defaulted one
+func patchResponse(req admission.Request, defaulted interface{}) admission.Response {
+	current, err := json.Marshal(defaulted)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, current)
+}
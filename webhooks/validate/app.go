@@ -2,129 +2,197 @@ package validate
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
 	"fmt"
-	"io/ioutil"
+	"net/http"
+	"time"
 
-	"cloudfoundry.org/cf-crd-explorations/cfshim/filters"
 	v1 "k8s.io/api/admission/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-
-	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
 )
 
+const (
+	// AppNameLockPrefix prefixes the Leases that reserve an App name within a namespace
+	AppNameLockPrefix = "app-name-"
+	// AppNameAnnotation records the reserved App name on the Lease, since the Lease name is a hash of it
+	AppNameAnnotation = "apps.cloudfoundry.org/appName"
+	// AppNameHolderLabel labels the Lease with the GUID of the App holding it, like the objects of the App are labelled
+	AppNameHolderLabel = "apps.cloudfoundry.org/appGuid"
+	// AppNameLeaseGracePeriod is how long a Lease is kept for an App that is not stored yet. The App is admitted before
+	// it is stored, so until then its Lease looks like the Lease of a deleted App. It outlasts the webhook timeouts
+	AppNameLeaseGracePeriod = 30 * time.Second
+)
+
 /*
 		For how to configure the Webhook with kubeapi
 	    See: https://docs.giantswarm.io/advanced/custom-admission-controller/
 */
+
+// AppValidator keeps App names unique per namespace. Each name is reserved by a Lease in the App's namespace, named
+// after a hash of the App name and held by the App GUID. Creating the Lease is atomic in the API server, so two
+// concurrent requests for the same name cannot both succeed, which a list-and-compare check cannot guarantee.
 type AppValidator struct {
 	// This is a Kuberentes client, contains authentication and context stuff for running K8s queries
+	// It should not be backed by the manager cache, a stale read could hand out a name that is already taken
 	KubeClient client.Client
+	decoder    *admission.Decoder
 }
 
-type Filter interface {
-	// Filter takes an object, casts it uses preset filters and returns yes/no
-	Filter(interface{}) bool
-}
-
-func (a *AppValidator) AppValidation(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("validation App name is unique...")
-	var appRequest *appsv1alpha1.App
-	arRequest := v1.AdmissionReview{}
+func (a *AppValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	switch req.Operation {
+	case v1.Create:
+		app := &appsv1alpha1.App{}
+		if err := a.decoder.Decode(req, app); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		return a.reserveName(ctx, app, isDryRun(req))
 
-	var body []byte
-	if r.Body != nil {
-		if data, err := ioutil.ReadAll(r.Body); err == nil {
-			body = data
+	case v1.Update:
+		app := &appsv1alpha1.App{}
+		if err := a.decoder.Decode(req, app); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		oldApp := &appsv1alpha1.App{}
+		if err := a.decoder.DecodeRaw(req.OldObject, oldApp); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
 		}
+		if app.Spec.Name == oldApp.Spec.Name {
+			return admission.Allowed("")
+		}
+		// The old name is not released here, the update may still be rejected after admission. Its Lease is taken
+		// over by the next App with that name once this App no longer has it, see isStale
+		return a.reserveName(ctx, app, isDryRun(req))
 	}
 
-	if err := json.Unmarshal(body, &arRequest); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode("Bad Request")
-		return
-	}
+	return admission.Allowed("")
+}
 
-	if err := json.Unmarshal(arRequest.Request.Object.Raw, &appRequest); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode("Bad Request")
-		fmt.Println("error deserializing - Bad Request")
-		return
-	}
+// InjectDecoder is called by the webhook server, see admission.DecoderInjector
+func (a *AppValidator) InjectDecoder(d *admission.Decoder) error {
+	a.decoder = d
+	return nil
+}
 
-	appName := appRequest.Spec.Name
-	appNamespace := appRequest.Namespace
+// reserveName takes the Lease for the App name, or denies the request if another App in the namespace holds it
+func (a *AppValidator) reserveName(ctx context.Context, app *appsv1alpha1.App, dryRun bool) admission.Response {
+	lockKey := types.NamespacedName{Name: AppNameLockName(app.Spec.Name), Namespace: app.Namespace}
 
-	queryParameters := map[string][]string{
-		"names": {appName},
+	lock := &coordinationv1.Lease{}
+	err := a.KubeClient.Get(ctx, lockKey, lock)
+	if apierrors.IsNotFound(err) {
+		if dryRun {
+			return admission.Allowed("")
+		}
+		lock = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        lockKey.Name,
+				Namespace:   lockKey.Namespace,
+				Annotations: map[string]string{AppNameAnnotation: app.Spec.Name},
+			},
+		}
+		hold(lock, app)
+		err = a.KubeClient.Create(ctx, lock)
+		if apierrors.IsAlreadyExists(err) {
+			return nameTakenResponse(app)
+		}
 	}
-
-	var filter Filter = &filters.AppFilter{
-		QueryParameters: queryParameters,
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
-	fmt.Println("********* About to make a GET request *******************")
-
-	AllApps := &appsv1alpha1.AppList{}
-	err := a.KubeClient.List(context.Background(), AllApps, client.InNamespace(appNamespace))
+	holder := ""
+	if lock.Spec.HolderIdentity != nil {
+		holder = *lock.Spec.HolderIdentity
+	}
+	if holder == app.Name {
+		return admission.Allowed("")
+	}
 
+	// The Lease is left behind when its App was never persisted, or is deleted or renamed before the App controller
+	// cleaned up after it, take it over in those cases
+	stale, err := a.isStale(ctx, lock, holder)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError) // What should the error be?
-		errorMessage := fmt.Sprintf("error fetching app: %v\n", err)
-		json.NewEncoder(w).Encode(errorMessage)
-		fmt.Print(errorMessage)
-		return
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if !stale {
+		return nameTakenResponse(app)
+	}
+	if dryRun {
+		return admission.Allowed("")
 	}
 
-	fmt.Printf("******************** Fetching all Apps in %s namespace %v", appNamespace, AllApps)
-
-	// Check if any of the apps in the namespace match the spec.name filter
-	var arResponseAllowed bool = true
-	for i, _ := range AllApps.Items {
-		if filter.Filter(&AllApps.Items[i]) {
-			fmt.Printf("matched app: %v", AllApps.Items[i])
-			arResponseAllowed = false
-			break
+	// The update carries the resourceVersion we read, so only one of two concurrent takeovers can win
+	hold(lock, app)
+	if err := a.KubeClient.Update(ctx, lock); err != nil {
+		if apierrors.IsConflict(err) {
+			return nameTakenResponse(app)
 		}
+		return admission.Errored(http.StatusInternalServerError, err)
 	}
+	return admission.Allowed("")
+}
 
-	// If there was a colliding name in the same namespace, the request is not allowed and we create a response
-	var arResponseResult *metav1.Status = nil
-
-	if !arResponseAllowed {
-		message := fmt.Sprintf("App with the name, %s already exists in namespace %s", appName, appNamespace)
-		arResponseResult = &metav1.Status{
-			Message: message,
-		}
+// hold makes the App the holder of the Lease, as of now. The App controller makes the holder own the Lease once it is
+// stored, so it is deleted along with the App. A previous holder that was renamed still exists, so its ownership is dropped
+func hold(lock *coordinationv1.Lease, app *appsv1alpha1.App) {
+	now := metav1.NewMicroTime(time.Now())
+	if lock.Labels == nil {
+		lock.Labels = map[string]string{}
 	}
+	lock.Labels[AppNameHolderLabel] = app.Name
+	lock.OwnerReferences = nil
+	lock.Spec.HolderIdentity = &app.Name
+	lock.Spec.AcquireTime = &now
+	lock.Spec.RenewTime = &now
+}
 
-	arResponse := v1.AdmissionReview{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "AdmissionReview",
-			APIVersion: "admission.k8s.io/v1",
-		},
-		Response: &v1.AdmissionResponse{
-			UID:     arRequest.Request.UID,
-			Allowed: arResponseAllowed,
-			Result:  arResponseResult,
-		},
+// isStale is true if the App holding the Lease no longer exists or no longer has the name the Lease reserves. An App
+// that does not exist may still be on its way to being stored, so its Lease is only stale after the grace period
+func (a *AppValidator) isStale(ctx context.Context, lock *coordinationv1.Lease, holder string) (bool, error) {
+	if holder == "" {
+		return true, nil
 	}
 
-	resp, err := json.Marshal(&arResponse)
+	holderApp := &appsv1alpha1.App{}
+	err := a.KubeClient.Get(ctx, types.NamespacedName{Name: holder, Namespace: lock.Namespace}, holderApp)
+	if apierrors.IsNotFound(err) {
+		return !IsRecentlyHeld(lock), nil
+	}
 	if err != nil {
-		fmt.Printf("Can't encode response: %v\n", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(fmt.Sprintf("could not encode response: %v", err))
-		return
+		return false, err
 	}
-	fmt.Printf("Ready to write reponse ...: %v \n", string(resp))
-	if _, err := w.Write(resp); err != nil {
-		fmt.Printf("Can't write response: %v\n", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(fmt.Sprintf("could not write response: %v", err))
-		return
+	return holderApp.Spec.Name != lock.Annotations[AppNameAnnotation], nil
+}
+
+// IsRecentlyHeld is true if the Lease was taken within the grace period, see AppNameLeaseGracePeriod
+func IsRecentlyHeld(lock *coordinationv1.Lease) bool {
+	heldSince := lock.CreationTimestamp.Time
+	if lock.Spec.RenewTime != nil {
+		heldSince = lock.Spec.RenewTime.Time
 	}
+	return time.Since(heldSince) < AppNameLeaseGracePeriod
+}
+
+// AppNameLockName returns the name of the Lease reserving an App name, App names are not valid object names so they are hashed
+func AppNameLockName(appName string) string {
+	return fmt.Sprintf("%s%x", AppNameLockPrefix, sha256.Sum256([]byte(appName)))
+}
+
+// nameTakenResponse uses the AlreadyExists reason, which the API server passes on to the client that made the request
+func nameTakenResponse(app *appsv1alpha1.App) admission.Response {
+	response := admission.Denied(fmt.Sprintf("App with the name, %s already exists in namespace %s", app.Spec.Name, app.Namespace))
+	response.Result.Code = http.StatusConflict
+	response.Result.Reason = metav1.StatusReasonAlreadyExists
+	return response
+}
+
+func isDryRun(req admission.Request) bool {
+	return req.DryRun != nil && *req.DryRun
 }
@@ -0,0 +1,166 @@
+package validate_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/webhooks/validate"
+	v1 "k8s.io/api/admission/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestAppValidatorNameUniqueness(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = appsv1alpha1.AddToScheme(scheme)
+
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	validator := &validate.AppValidator{KubeClient: kubeClient}
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = validator.InjectDecoder(decoder)
+
+	ctx := context.Background()
+	appA := newApp("app-guid-a", "my-app")
+	appB := newApp("app-guid-b", "my-app")
+
+	if response := validator.Handle(ctx, appRequest(t, v1.Create, appA, nil)); !response.Allowed {
+		t.Fatalf("expected the first App to be allowed, got %v", response.Result)
+	}
+	if err := kubeClient.Create(ctx, appA); err != nil {
+		t.Fatal(err)
+	}
+
+	response := validator.Handle(ctx, appRequest(t, v1.Create, appB, nil))
+	if response.Allowed || response.Result.Code != http.StatusConflict || response.Result.Reason != metav1.StatusReasonAlreadyExists {
+		t.Errorf("expected a second App with the same name to be denied as AlreadyExists, got %v", response.Result)
+	}
+
+	// Updating an App without renaming it must not collide with itself
+	relabelled := appA.DeepCopy()
+	relabelled.Labels = map[string]string{"env": "prod"}
+	if response := validator.Handle(ctx, appRequest(t, v1.Update, relabelled, appA)); !response.Allowed {
+		t.Errorf("expected an update that keeps the name to be allowed, got %v", response.Result)
+	}
+
+	// A rename that is admitted but never stored keeps the old name reserved
+	renamed := appA.DeepCopy()
+	renamed.Spec.Name = "my-renamed-app"
+	if response := validator.Handle(ctx, appRequest(t, v1.Update, renamed, appA)); !response.Allowed {
+		t.Errorf("expected the rename to be allowed, got %v", response.Result)
+	}
+	if response := validator.Handle(ctx, appRequest(t, v1.Create, appB, nil)); response.Allowed {
+		t.Errorf("expected the old name to stay taken until the rename is stored")
+	}
+
+	// Once it is stored, the old name is taken over by the next App with it
+	if err := kubeClient.Update(ctx, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if response := validator.Handle(ctx, appRequest(t, v1.Create, appB, nil)); !response.Allowed {
+		t.Errorf("expected the old name to be free after the rename, got %v", response.Result)
+	}
+
+	// The name of a deleted App is taken over once the grace period for Apps that are not stored yet is over
+	if err := kubeClient.Delete(ctx, renamed); err != nil {
+		t.Fatal(err)
+	}
+	appC := newApp("app-guid-c", "my-renamed-app")
+	if response := validator.Handle(ctx, appRequest(t, v1.Create, appC, nil)); response.Allowed {
+		t.Errorf("expected the name of the deleted App to stay taken within the grace period")
+	}
+	lockKey := types.NamespacedName{Name: validate.AppNameLockName("my-renamed-app"), Namespace: "space-guid"}
+	lock := &coordinationv1.Lease{}
+	if err := kubeClient.Get(ctx, lockKey, lock); err != nil {
+		t.Fatal(err)
+	}
+	heldSince := metav1.NewMicroTime(time.Now().Add(-validate.AppNameLeaseGracePeriod))
+	lock.Spec.RenewTime = &heldSince
+	lock.OwnerReferences = []metav1.OwnerReference{appsv1alpha1.AppOwnerReference(renamed)}
+	if err := kubeClient.Update(ctx, lock); err != nil {
+		t.Fatal(err)
+	}
+	if response := validator.Handle(ctx, appRequest(t, v1.Create, appC, nil)); !response.Allowed {
+		t.Errorf("expected the name of the deleted App to be free, got %v", response.Result)
+	}
+	lock = &coordinationv1.Lease{}
+	if err := kubeClient.Get(ctx, lockKey, lock); err != nil || *lock.Spec.HolderIdentity != "app-guid-c" {
+		t.Errorf("expected the new App to hold the name, got %v", err)
+	}
+	if lock.Labels[validate.AppNameHolderLabel] != "app-guid-c" || len(lock.OwnerReferences) != 0 {
+		t.Errorf("expected the Lease to be labelled with the new App and no longer owned by the deleted one, got %v and %v", lock.Labels, lock.OwnerReferences)
+	}
+}
+
+func TestAppValidatorConcurrentCreates(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = appsv1alpha1.AddToScheme(scheme)
+
+	validator := &validate.AppValidator{KubeClient: fake.NewClientBuilder().WithScheme(scheme).Build()}
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = validator.InjectDecoder(decoder)
+
+	// Neither App is stored while the other one is admitted, so only the Lease can tell that the name is taken
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("my-app-%d", i)
+		responses := make([]admission.Response, 2)
+		var wg sync.WaitGroup
+		for j := range responses {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				app := newApp(fmt.Sprintf("app-guid-%d-%d", i, j), name)
+				responses[j] = validator.Handle(context.Background(), appRequest(t, v1.Create, app, nil))
+			}(j)
+		}
+		wg.Wait()
+		if responses[0].Allowed == responses[1].Allowed {
+			t.Errorf("expected exactly one of two concurrent Apps named %s to be allowed, got %v and %v", name, responses[0].Result, responses[1].Result)
+		}
+	}
+}
+
+func newApp(guid, name string) *appsv1alpha1.App {
+	return &appsv1alpha1.App{
+		TypeMeta:   metav1.TypeMeta{Kind: "App", APIVersion: appsv1alpha1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: guid, Namespace: "space-guid"},
+		Spec:       appsv1alpha1.AppSpec{Name: name},
+	}
+}
+
+func appRequest(t *testing.T, operation v1.Operation, app, oldApp *appsv1alpha1.App) admission.Request {
+	req := admission.Request{AdmissionRequest: v1.AdmissionRequest{Operation: operation}}
+	if app != nil {
+		raw, err := json.Marshal(app)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Object.Raw = raw
+	}
+	if oldApp != nil {
+		raw, err := json.Marshal(oldApp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.OldObject.Raw = raw
+	}
+	return req
+}
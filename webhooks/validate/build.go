@@ -0,0 +1,96 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	v1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// BuildValidator rejects Builds whose Package or App do not exist, which would leave the BuildReconciler retrying forever
+type BuildValidator struct {
+	// This is a Kuberentes client, contains authentication and context stuff for running K8s queries
+	KubeClient client.Client
+	decoder    *admission.Decoder
+}
+
+func (b *BuildValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	// References are only checked on create, so an object whose App or Package was deleted can still be updated and cleaned up
+	if req.Operation != v1.Create {
+		return admission.Allowed("")
+	}
+
+	build := &appsv1alpha1.Build{}
+	if err := b.decoder.Decode(req, build); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	validationErrors, err := b.validateBuild(ctx, build)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return validationResponse(validationErrors)
+}
+
+// InjectDecoder is called by the webhook server, see admission.DecoderInjector
+func (b *BuildValidator) InjectDecoder(d *admission.Decoder) error {
+	b.decoder = d
+	return nil
+}
+
+func (b *BuildValidator) validateBuild(ctx context.Context, build *appsv1alpha1.Build) ([]string, error) {
+	var validationErrors []string
+
+	pk := &appsv1alpha1.Package{}
+	err := b.KubeClient.Get(ctx, types.NamespacedName{Name: build.Spec.PackageRef.Name, Namespace: build.Namespace}, pk)
+	if apierrors.IsNotFound(err) {
+		validationErrors = append(validationErrors, fmt.Sprintf("Package %s does not exist in namespace %s", build.Spec.PackageRef.Name, build.Namespace))
+		pk = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	app := &appsv1alpha1.App{}
+	err = b.KubeClient.Get(ctx, types.NamespacedName{Name: build.Spec.AppRef.Name, Namespace: build.Namespace}, app)
+	if apierrors.IsNotFound(err) {
+		validationErrors = append(validationErrors, fmt.Sprintf("App %s does not exist in namespace %s", build.Spec.AppRef.Name, build.Namespace))
+		app = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return append(validationErrors, ValidateBuildReferences(build, pk, app)...), nil
+}
+
+// ValidateBuildReferences checks that the lifecycle type of the Build agrees with its Package and App
+// A nil Package or App is skipped, as its absence is reported separately
+func ValidateBuildReferences(build *appsv1alpha1.Build, pk *appsv1alpha1.Package, app *appsv1alpha1.App) []string {
+	var validationErrors []string
+
+	if pk != nil {
+		if pk.Spec.AppRef.Name != build.Spec.AppRef.Name {
+			validationErrors = append(validationErrors, fmt.Sprintf("Package %s belongs to App %s, not %s", pk.Name, pk.Spec.AppRef.Name, build.Spec.AppRef.Name))
+		}
+
+		expectedPackageType := appsv1alpha1.BitsPackage
+		if build.Spec.Type == appsv1alpha1.DockerLifecycle {
+			expectedPackageType = appsv1alpha1.DockerPackage
+		}
+		if pk.Spec.Type != expectedPackageType {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s builds require a %s package, Package %s is %s", build.Spec.Type, expectedPackageType, pk.Name, pk.Spec.Type))
+		}
+	}
+
+	// Apps created before the lifecycle type was required may not have one set
+	if app != nil && app.Spec.Type != "" && app.Spec.Type != build.Spec.Type {
+		validationErrors = append(validationErrors, fmt.Sprintf("Build lifecycle type %s does not match App %s lifecycle type %s", build.Spec.Type, app.Name, app.Spec.Type))
+	}
+
+	return validationErrors
+}
@@ -0,0 +1,74 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// DropletValidator rejects Droplets that reference a missing App or an unparseable image
+type DropletValidator struct {
+	// This is a Kuberentes client, contains authentication and context stuff for running K8s queries
+	KubeClient client.Client
+	decoder    *admission.Decoder
+}
+
+func (d *DropletValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	// References are only checked on create, so an object whose App or Package was deleted can still be updated and cleaned up
+	if req.Operation != v1.Create {
+		return admission.Allowed("")
+	}
+
+	droplet := &appsv1alpha1.Droplet{}
+	if err := d.decoder.Decode(req, droplet); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	validationErrors, err := d.validateDroplet(ctx, droplet)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return validationResponse(validationErrors)
+}
+
+// InjectDecoder is called by the webhook server, see admission.DecoderInjector
+func (d *DropletValidator) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+func (d *DropletValidator) validateDroplet(ctx context.Context, droplet *appsv1alpha1.Droplet) ([]string, error) {
+	validationErrors := ValidateDroplet(droplet)
+
+	app := &appsv1alpha1.App{}
+	err := d.KubeClient.Get(ctx, types.NamespacedName{Name: droplet.Spec.AppRef.Name, Namespace: droplet.Namespace}, app)
+	if apierrors.IsNotFound(err) {
+		validationErrors = append(validationErrors, fmt.Sprintf("App %s does not exist in namespace %s", droplet.Spec.AppRef.Name, droplet.Namespace))
+	} else if err != nil {
+		return nil, err
+	}
+
+	return validationErrors, nil
+}
+
+// ValidateDroplet returns the list of reasons the Droplet spec is invalid, or nil if it is valid
+func ValidateDroplet(droplet *appsv1alpha1.Droplet) []string {
+	var validationErrors []string
+
+	image := droplet.Spec.Registry.Image
+	if image == "" {
+		validationErrors = append(validationErrors, "spec.registry.image is required")
+	} else if _, err := name.ParseReference(image); err != nil {
+		validationErrors = append(validationErrors, fmt.Sprintf("spec.registry.image %q is not a valid image reference: %v", image, err))
+	}
+
+	return validationErrors
+}
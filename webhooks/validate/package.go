@@ -0,0 +1,57 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// PackageValidator rejects Packages that the staging flow would not be able to consume
+type PackageValidator struct {
+	decoder *admission.Decoder
+}
+
+func (p *PackageValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation == v1.Delete {
+		return admission.Allowed("")
+	}
+
+	pk := &appsv1alpha1.Package{}
+	if err := p.decoder.Decode(req, pk); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	return validationResponse(ValidatePackage(pk))
+}
+
+// InjectDecoder is called by the webhook server, see admission.DecoderInjector
+func (p *PackageValidator) InjectDecoder(d *admission.Decoder) error {
+	p.decoder = d
+	return nil
+}
+
+// ValidatePackage returns the list of reasons the Package is invalid, or nil if it is valid
+func ValidatePackage(pk *appsv1alpha1.Package) []string {
+	var validationErrors []string
+
+	if pk.Spec.AppRef.Name == "" {
+		validationErrors = append(validationErrors, "spec.appRef.name is required")
+	}
+
+	// Bits packages only get their image once the source is uploaded, docker packages are unusable without one
+	image := pk.Spec.Source.Registry.Image
+	if pk.Spec.Type == appsv1alpha1.DockerPackage && image == "" {
+		validationErrors = append(validationErrors, "spec.source.registry.image is required for docker packages")
+	}
+	if image != "" {
+		if _, err := name.ParseReference(image); err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("spec.source.registry.image %q is not a valid image reference: %v", image, err))
+		}
+	}
+
+	return validationErrors
+}
@@ -0,0 +1,62 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	v1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ProcessValidator rejects Processes that could never be turned into a running LRP
+type ProcessValidator struct {
+	decoder *admission.Decoder
+}
+
+func (p *ProcessValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation == v1.Delete {
+		return admission.Allowed("")
+	}
+
+	process := &appsv1alpha1.Process{}
+	if err := p.decoder.Decode(req, process); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	return validationResponse(ValidateProcess(process))
+}
+
+// InjectDecoder is called by the webhook server, see admission.DecoderInjector
+func (p *ProcessValidator) InjectDecoder(d *admission.Decoder) error {
+	p.decoder = d
+	return nil
+}
+
+// ValidateProcess returns the list of reasons the Process is invalid, or nil if it is valid
+func ValidateProcess(process *appsv1alpha1.Process) []string {
+	var validationErrors []string
+
+	if process.Spec.MemoryMB <= 0 {
+		validationErrors = append(validationErrors, fmt.Sprintf("spec.memoryMB must be greater than 0, got %d", process.Spec.MemoryMB))
+	}
+	if process.Spec.DiskQuotaMB <= 0 {
+		validationErrors = append(validationErrors, fmt.Sprintf("spec.diskQuotaMB must be greater than 0, got %d", process.Spec.DiskQuotaMB))
+	}
+	if process.Spec.Instances < 0 {
+		validationErrors = append(validationErrors, fmt.Sprintf("spec.instances must not be negative, got %d", process.Spec.Instances))
+	}
+
+	switch process.Spec.HealthCheck.Type {
+	case appsv1alpha1.HTTPHealthCheckType, appsv1alpha1.PortHealthCheckType:
+		// The LRP health check probes the first port, so there has to be one
+		if len(process.Spec.Ports) == 0 {
+			validationErrors = append(validationErrors, fmt.Sprintf("spec.ports must not be empty when the health check type is %s", process.Spec.HealthCheck.Type))
+		}
+	case appsv1alpha1.ProcessHealthCheckType:
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("spec.healthCheck.type %q must be one of http, port, process", process.Spec.HealthCheck.Type))
+	}
+
+	return validationErrors
+}
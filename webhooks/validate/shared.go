@@ -0,0 +1,15 @@
+package validate
+
+import (
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// validationResponse allows the request if no validation errors were found and otherwise denies it with all of them
+func validationResponse(validationErrors []string) admission.Response {
+	if len(validationErrors) > 0 {
+		return admission.Denied(strings.Join(validationErrors, ", "))
+	}
+	return admission.Allowed("")
+}
@@ -0,0 +1,63 @@
+package validate_test
+
+import (
+	"testing"
+
+	appsv1alpha1 "cloudfoundry.org/cf-crd-explorations/api/v1alpha1"
+	"cloudfoundry.org/cf-crd-explorations/webhooks/validate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateProcess(t *testing.T) {
+	process := &appsv1alpha1.Process{
+		Spec: appsv1alpha1.ProcessSpec{
+			HealthCheck: appsv1alpha1.HealthCheck{Type: appsv1alpha1.PortHealthCheckType},
+			MemoryMB:    500,
+			DiskQuotaMB: 512,
+			Ports:       []int32{8080},
+		},
+	}
+	if errs := validate.ValidateProcess(process); len(errs) != 0 {
+		t.Errorf("expected a valid process, got %v", errs)
+	}
+
+	process.Spec.Ports = nil
+	process.Spec.MemoryMB = 0
+	if errs := validate.ValidateProcess(process); len(errs) != 2 {
+		t.Errorf("expected errors for memory and ports, got %v", errs)
+	}
+
+	process.Spec.HealthCheck.Type = "tcp"
+	if errs := validate.ValidateProcess(process); len(errs) != 2 {
+		t.Errorf("expected errors for memory and health check type, got %v", errs)
+	}
+}
+
+func TestValidateBuildReferences(t *testing.T) {
+	build := &appsv1alpha1.Build{
+		Spec: appsv1alpha1.BuildSpec{
+			Type:   appsv1alpha1.DockerLifecycle,
+			AppRef: appsv1alpha1.ApplicationReference{Name: "my-app-guid"},
+		},
+	}
+	pk := &appsv1alpha1.Package{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-package-guid"},
+		Spec: appsv1alpha1.PackageSpec{
+			Type:   appsv1alpha1.BitsPackage,
+			AppRef: appsv1alpha1.ApplicationReference{Name: "my-app-guid"},
+		},
+	}
+	app := &appsv1alpha1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-app-guid"},
+		Spec:       appsv1alpha1.AppSpec{Type: appsv1alpha1.BuildpackLifecycle},
+	}
+
+	if errs := validate.ValidateBuildReferences(build, pk, app); len(errs) != 2 {
+		t.Errorf("expected package and app lifecycle mismatches, got %v", errs)
+	}
+
+	build.Spec.Type = appsv1alpha1.BuildpackLifecycle
+	if errs := validate.ValidateBuildReferences(build, pk, app); len(errs) != 0 {
+		t.Errorf("expected a valid build, got %v", errs)
+	}
+}